@@ -7,10 +7,12 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jicki/crds-objects-browser/pkg/api"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
@@ -19,9 +21,56 @@ import (
 func main() {
 	var kubeconfig string
 	var port string
+	options := api.DefaultServerOptions()
 
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig file")
 	flag.StringVar(&port, "port", "8080", "Port to run the server on")
+	flag.StringVar(&options.SnapshotFile, "snapshot-file", "", "Path to the on-disk cache snapshot used for fast restart (disabled if empty)")
+	flag.DurationVar(&options.SnapshotInterval, "snapshot-interval", options.SnapshotInterval, "Interval between cache snapshot saves")
+	flag.BoolVar(&options.ReadOnly, "read-only", options.ReadOnly, "Disable all mutating API endpoints (update, patch, apply, delete)")
+	flag.StringVar(&options.OfflineSource, "offline", "", "Browse a cluster dump (directory, tar/tar.gz archive or manifest file) instead of a live cluster")
+	flag.StringVar(&options.AuditLogPath, "audit-log-path", "", "Write audit events as JSON Lines to this file (disabled if empty)")
+	flag.IntVar(&options.AuditLogMaxSizeMB, "audit-log-maxsize", options.AuditLogMaxSizeMB, "Maximum size in megabytes of the audit log file before it is rotated")
+	flag.IntVar(&options.AuditLogMaxBackups, "audit-log-maxbackup", options.AuditLogMaxBackups, "Maximum number of rotated audit log files to keep")
+	flag.StringVar(&options.AuditWebhookURL, "audit-webhook-url", "", "POST batches of audit events to this URL (disabled if empty)")
+	flag.StringVar(&options.AuditPolicyFile, "audit-policy-file", "", "Path to the audit policy file (YAML or JSON)")
+	flag.StringVar(&options.AuditLevel, "audit-level", options.AuditLevel, "Audit level when no policy file is given: None, Metadata, Request or RequestResponse")
+	flag.Func("trusted-proxies", "Comma-separated CIDRs or IPs of authenticating proxies whose user headers are recorded in the audit log (users are recorded as anonymous if empty)", func(value string) error {
+		options.TrustedProxies = splitList(value)
+		return nil
+	})
+	flag.Func("audit-user-headers", "Comma-separated request headers a trusted proxy uses to pass the user name (default X-Remote-User,X-Forwarded-User,X-Auth-Request-User)", func(value string) error {
+		options.AuditUserHeaders = splitList(value)
+		return nil
+	})
+	flag.Func("audit-group-headers", "Comma-separated request headers a trusted proxy uses to pass the user groups (default X-Remote-Group,X-Forwarded-Groups,X-Auth-Request-Groups)", func(value string) error {
+		options.AuditGroupHeaders = splitList(value)
+		return nil
+	})
+	flag.Func("required-labels", "Comma-separated labels every object is expected to carry, reported by the label analytics endpoint (e.g. team,cost-center)", func(value string) error {
+		options.RequiredLabels = splitList(value)
+		return nil
+	})
+	flag.Func("index", "Additional informer index as <resource>.<version>[.<group>]=label:<key>|ownerUID|jsonpath:<path> (repeatable, e.g. deployments.v1.apps=label:app)", func(value string) error {
+		options.Indexes = append(options.Indexes, value)
+		return nil
+	})
+	flag.Func("protobuf-resources", "Comma-separated built-in resources as <resource>.<version>[.<group>] listed and watched with protobuf instead of JSON, \"all\" for every built-in resource, empty to disable (default pods.v1,events.v1)", func(value string) error {
+		options.ProtobufResources = splitList(value)
+		return nil
+	})
+	flag.BoolVar(&options.WatchList, "watch-list", options.WatchList, "Stream the initial informer sync with WatchList (sendInitialEvents) where the API server supports it, falling back to paginated LIST")
+	flag.Int64Var(&options.ListPageSize, "list-page-size", options.ListPageSize, "Page size of paginated informer LIST requests read from etcd, bypassing the watch cache (0 lists everything from the watch cache at once)")
+	flag.Func("memory-budget", "Approximate memory budget for all informer caches as a quantity (e.g. 2Gi); least recently used informers are evicted and served by live LIST when exceeded (disabled if empty)", func(value string) error {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return err
+		}
+		options.MemoryBudget = quantity.Value()
+		return nil
+	})
+	flag.IntVar(&options.ResponseCacheSize, "response-cache-size", options.ResponseCacheSize, "Maximum number of object and namespace list results kept in the response cache (0 only coalesces concurrent identical requests)")
+	flag.DurationVar(&options.ResponseCacheTTL, "response-cache-ttl", options.ResponseCacheTTL, "How long a cached list result is served before recomputing; results are also dropped as soon as the resource changes")
 	
 	// 初始化klog
 	klog.InitFlags(nil)
@@ -32,14 +81,18 @@ func main() {
 
 	klog.Info("Starting CRDs Objects Browser with Informer optimization")
 
-	// 创建Kubernetes配置
-	config, err := createKubeConfig(kubeconfig)
-	if err != nil {
-		log.Fatalf("Failed to create kube config: %v", err)
+	// 创建Kubernetes配置（离线模式不需要）
+	var config *rest.Config
+	if options.OfflineSource == "" {
+		var err error
+		config, err = createKubeConfig(kubeconfig)
+		if err != nil {
+			log.Fatalf("Failed to create kube config: %v", err)
+		}
 	}
 
 	// 创建API服务器
-	server, err := api.NewServer(config)
+	server, err := api.NewServer(config, options)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
@@ -127,3 +180,14 @@ func createKubeConfig(kubeconfig string) (*rest.Config, error) {
 
 	return config, nil
 }
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
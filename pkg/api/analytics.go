@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// resourceLabelReport 单个资源的标签分析结果
+type resourceLabelReport struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	informer.LabelReport
+}
+
+// cachedResourceList 返回当前已知的资源列表，不触发发现
+func (s *Server) cachedResourceList() []Resource {
+	if s.offlineCache != nil {
+		return s.offlineResources()
+	}
+
+	s.resourcesCacheMutex.RLock()
+	defer s.resourcesCacheMutex.RUnlock()
+	resources := make([]Resource, len(s.resourcesCache))
+	copy(resources, s.resourcesCache)
+	return resources
+}
+
+// requiredLabels 返回请求指定的必需标签，未指定时使用服务器配置
+func (s *Server) requiredLabels(c *gin.Context) []string {
+	value, ok := c.GetQuery("required")
+	if !ok {
+		return s.options.RequiredLabels
+	}
+
+	var labels []string
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// getLabelAnalytics 从缓存统计标签键、值、基数和注解键（按资源和命名空间），并列出缺少必需标签的对象
+// 指定resource时只分析该资源（必要时懒加载Informer），否则分析所有已就绪的资源
+func (s *Server) getLabelAnalytics(c *gin.Context) {
+	topValues, err := strconv.Atoi(c.DefaultQuery("topValues", "10"))
+	if err != nil || topValues < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "topValues must be a non-negative integer"})
+		return
+	}
+
+	namespace := c.Query("namespace")
+	required := s.requiredLabels(c)
+
+	var targets []schema.GroupVersionResource
+	if resource := c.Query("resource"); resource != "" {
+		group := c.Query("group")
+		if group == "core" {
+			group = ""
+		}
+		version := c.Query("version")
+		if version == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version is required when resource is set"})
+			return
+		}
+		targets = append(targets, schema.GroupVersionResource{Group: group, Version: version, Resource: resource})
+	} else {
+		for _, resource := range s.cachedResourceList() {
+			gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Name}
+			if s.strategyManager.IsReady(gvr) {
+				targets = append(targets, gvr)
+			}
+		}
+	}
+
+	reports := []resourceLabelReport{}
+	for _, gvr := range targets {
+		objects, err := s.getCachedObjects(c.Request.Context(), gvr, namespace)
+		if err != nil {
+			if len(targets) == 1 {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			klog.V(4).Infof("Skipping %s in label analytics: %v", gvr.String(), err)
+			continue
+		}
+		if len(objects) == 0 && len(targets) > 1 {
+			continue
+		}
+
+		reports = append(reports, resourceLabelReport{
+			Group:       gvr.Group,
+			Version:     gvr.Version,
+			Resource:    gvr.Resource,
+			LabelReport: informer.AggregateLabels(objects, required, topValues),
+		})
+	}
+
+	if required == nil {
+		required = []string{}
+	}
+	writeJSONList(c, reports, "resources", gin.H{"required": required})
+}
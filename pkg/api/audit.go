@@ -0,0 +1,313 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/audit"
+)
+
+// requestIDHeader 请求ID头，客户端未提供时自动生成
+const requestIDHeader = "X-Request-ID"
+
+// requestIDKey 请求ID在gin.Context中的键
+const requestIDKey = "requestID"
+
+// maxAuditBodySize 审计记录中请求体和响应体的大小上限，超出部分不记录
+const maxAuditBodySize = 64 * 1024
+
+// DefaultUserHeaders 认证代理传递用户名的默认请求头，按顺序取第一个非空值
+var DefaultUserHeaders = []string{"X-Remote-User", "X-Forwarded-User", "X-Auth-Request-User"}
+
+// DefaultGroupHeaders 认证代理传递用户组的默认请求头
+var DefaultGroupHeaders = []string{"X-Remote-Group", "X-Forwarded-Groups", "X-Auth-Request-Groups"}
+
+// parseTrustedProxies 解析可信代理的CIDR或IP地址
+func parseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// fromTrustedProxy 检查请求的直接来源是否为可信代理，不使用X-Forwarded-For等可伪造的头
+func (s *Server) fromTrustedProxy(r *http.Request) bool {
+	if len(s.trustedProxies) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAuditLogger 根据服务器选项创建审计日志记录器，未配置输出时返回nil
+func newAuditLogger(options *ServerOptions) (*audit.Logger, error) {
+	if options.AuditLogPath == "" && options.AuditWebhookURL == "" {
+		return nil, nil
+	}
+
+	var policy *audit.Policy
+	if options.AuditPolicyFile != "" {
+		loaded, err := audit.LoadPolicy(options.AuditPolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		policy = loaded
+	} else {
+		level, err := audit.ParseLevel(options.AuditLevel)
+		if err != nil {
+			return nil, err
+		}
+		policy = audit.DefaultPolicy(level)
+	}
+
+	var sinks []audit.Sink
+	if options.AuditLogPath != "" {
+		fileSink, err := audit.NewFileSink(options.AuditLogPath, options.AuditLogMaxSizeMB, options.AuditLogMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fileSink)
+	}
+	if options.AuditWebhookURL != "" {
+		sinks = append(sinks, audit.NewWebhookSink(options.AuditWebhookURL))
+	}
+
+	return audit.NewLogger(policy, sinks...), nil
+}
+
+// requestIDMiddleware 为每个请求分配请求ID并写入响应头
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// auditResponseWriter 记录响应体前maxAuditBodySize字节
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+// Write 写入响应并保留副本
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if remaining := maxAuditBodySize - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			w.body.Write(data[:remaining])
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// auditMiddleware 记录每个API访问和写操作的审计事件
+func (s *Server) auditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auditLogger == nil || !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		event := &audit.Event{
+			Timestamp: start,
+			RequestID: c.GetString(requestIDKey),
+			User:      s.requestUser(c),
+			SourceIP:  c.ClientIP(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Action:    auditAction(c),
+			Group:     c.Param("group"),
+			Version:   c.Param("version"),
+			Resource:  c.Param("resource"),
+			Namespace: c.Query("namespace"),
+			Name:      c.Param("name"),
+			DryRun:    c.Query("dryRun") != "",
+		}
+		if event.Group == "core" {
+			event.Group = ""
+		}
+		if event.Namespace == "" {
+			event.Namespace = c.Param("namespace")
+		}
+		if event.Resource == "" && strings.HasPrefix(c.FullPath(), "/api/pods/") {
+			event.Version, event.Resource = "v1", "pods"
+		}
+
+		event.Level = s.auditLogger.LevelFor(event)
+		if event.Level == audit.LevelNone {
+			c.Next()
+			return
+		}
+
+		// Request及以上级别：读取请求体后放回，供处理函数继续使用；
+		// 请求体过大时直接返回413，不把截断的请求体交给处理函数
+		if !event.Level.Less(audit.LevelRequest) && c.Request.Body != nil {
+			if body, ok := readMutationBody(c); ok {
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				// 处理函数收到完整的请求体，审计记录与响应体一样只保留前maxAuditBodySize字节
+				if len(body) > maxAuditBodySize {
+					body = body[:maxAuditBodySize]
+				}
+				event.RequestBody = auditBody(body)
+			}
+		}
+
+		var writer *auditResponseWriter
+		if !event.Level.Less(audit.LevelRequestResponse) {
+			writer = &auditResponseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+			c.Writer = writer
+		}
+
+		c.Next()
+
+		event.StatusCode = c.Writer.Status()
+		event.LatencyMs = time.Since(start).Milliseconds()
+		event.Result = audit.ResultSuccess
+		if event.StatusCode >= 400 {
+			event.Result = audit.ResultFailure
+		}
+		// 压缩的响应体不可读，不记录
+		if writer != nil && writer.Header().Get("Content-Encoding") == "" {
+			event.ResponseBody = auditBody(writer.body.Bytes())
+		}
+
+		s.auditLogger.Log(event)
+	}
+}
+
+// auditAction 根据请求方法和路由推断操作类型
+func auditAction(c *gin.Context) string {
+	route := c.FullPath()
+	switch c.Request.Method {
+	case "PUT":
+		return "update"
+	case "PATCH":
+		if strings.HasPrefix(c.GetHeader("Content-Type"), "application/apply-patch") {
+			return "apply"
+		}
+		return "patch"
+	case "DELETE":
+		if strings.HasPrefix(route, "/api/migrations/") {
+			return "cancel-migration"
+		}
+		return "delete"
+	case "POST":
+		switch {
+		case strings.HasPrefix(route, "/api/migrations/"):
+			return "migrate"
+		case strings.HasSuffix(route, "/scale"):
+			return "scale"
+		case strings.HasSuffix(route, "/restart"):
+			return "restart"
+		case strings.HasSuffix(route, "/rollback"):
+			return "rollback"
+		}
+		return "create"
+	}
+
+	switch {
+	case strings.HasSuffix(route, "/export"):
+		return "export"
+	case strings.HasSuffix(route, "/logs"):
+		return "logs"
+	case strings.HasSuffix(route, "/objects"), strings.HasSuffix(route, "/objects/fast"):
+		return "list"
+	default:
+		return "get"
+	}
+}
+
+// requestUser 从可信认证代理设置的请求头中获取用户信息
+// 请求头可被任意客户端设置，只在请求直接来自可信代理时读取，否则记录为anonymous
+func (s *Server) requestUser(c *gin.Context) audit.UserInfo {
+	user := audit.UserInfo{Username: "anonymous"}
+	if !s.fromTrustedProxy(c.Request) {
+		return user
+	}
+
+	for _, header := range s.options.AuditUserHeaders {
+		if value := c.GetHeader(header); value != "" {
+			user.Username = value
+			break
+		}
+	}
+	for _, header := range s.options.AuditGroupHeaders {
+		if value := c.GetHeader(header); value != "" {
+			for _, group := range strings.Split(value, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					user.Groups = append(user.Groups, group)
+				}
+			}
+			break
+		}
+	}
+	return user
+}
+
+// auditBody 将请求体或响应体转为JSON，非JSON内容（包括被截断的JSON）按字符串记录
+func auditBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	encoded, err := json.Marshal(string(body))
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(encoded)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/capacity"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+var (
+	podsGVR          = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resourceQuotaGVR = schema.GroupVersionResource{Version: "v1", Resource: "resourcequotas"}
+	limitRangeGVR    = schema.GroupVersionResource{Version: "v1", Resource: "limitranges"}
+)
+
+// getCapacityReport 从缓存的Pod汇总资源请求和限制（按命名空间、节点和工作负载），
+// 并与ResourceQuota和LimitRange对比
+func (s *Server) getCapacityReport(c *gin.Context) {
+	namespace := c.Query("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+
+	pods, err := s.getCachedObjects(c.Request.Context(), podsGVR, namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 配额和限制范围不存在或无权读取时仍返回Pod汇总
+	quotas := s.optionalCachedObjects(c.Request.Context(), resourceQuotaGVR, namespace)
+	limitRanges := s.optionalCachedObjects(c.Request.Context(), limitRangeGVR, namespace)
+
+	c.JSON(http.StatusOK, capacity.BuildReport(pods, quotas, limitRanges))
+}
+
+// optionalCachedObjects 读取缓存对象，失败时记录日志并返回空列表
+func (s *Server) optionalCachedObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string) []*unstructured.Unstructured {
+	objects, err := s.getCachedObjects(ctx, gvr, namespace)
+	if err != nil {
+		klog.V(2).Infof("Skipping %s in capacity report: %v", gvr.String(), err)
+		return nil
+	}
+	return objects
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/diff"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// crdGVR CRD资源，使用动态客户端读取以避免引入apiextensions客户端
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// conversionIgnoredFields 版本比较时忽略的字段
+var conversionIgnoredFields = append([]string{"apiVersion"}, diff.DefaultIgnoredFields...)
+
+// crdDefinition CRD中与版本相关的定义
+type crdDefinition struct {
+	Name               string
+	Group              string
+	Plural             string
+	Namespaced         bool
+	StorageVersion     string
+	ServedVersions     []string
+	StoredVersions     []string
+	ConversionStrategy string
+}
+
+// gvr 返回指定版本的资源
+func (d *crdDefinition) gvr(version string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: d.Group, Version: version, Resource: d.Plural}
+}
+
+// pendingStoredVersions 返回status.storedVersions中除存储版本外仍待迁移的版本
+func (d *crdDefinition) pendingStoredVersions() []string {
+	pending := []string{}
+	for _, version := range d.StoredVersions {
+		if version != d.StorageVersion {
+			pending = append(pending, version)
+		}
+	}
+	return pending
+}
+
+// getCRDDefinition 读取并解析CRD
+func (s *Server) getCRDDefinition(ctx context.Context, name string) (*unstructured.Unstructured, *crdDefinition, error) {
+	crd, err := s.dynamicClient.Resource(crdGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	definition := &crdDefinition{Name: name, ConversionStrategy: "None"}
+	definition.Group, _, _ = unstructured.NestedString(crd.Object, "spec", "group")
+	definition.Plural, _, _ = unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+	definition.Namespaced = scope != "Cluster"
+	if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "" {
+		definition.ConversionStrategy = strategy
+	}
+	definition.StoredVersions, _, _ = unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, raw := range versions {
+		version, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		versionName, _ := version["name"].(string)
+		if served, _ := version["served"].(bool); served {
+			definition.ServedVersions = append(definition.ServedVersions, versionName)
+		}
+		if storage, _ := version["storage"].(bool); storage {
+			definition.StorageVersion = versionName
+		}
+	}
+
+	if definition.StorageVersion == "" {
+		return nil, nil, fmt.Errorf("CRD %s has no storage version", name)
+	}
+	return crd, definition, nil
+}
+
+// versionClient 返回指定版本（和命名空间）的动态客户端
+func (s *Server) versionClient(definition *crdDefinition, version, namespace string) dynamic.ResourceInterface {
+	client := s.dynamicClient.Resource(definition.gvr(version))
+	if definition.Namespaced && namespace != "" {
+		return client.Namespace(namespace)
+	}
+	return client
+}
+
+// versionResult 对象在某个版本下的读取结果
+type versionResult struct {
+	Version         string `json:"version"`
+	OK              bool   `json:"ok"`
+	Error           string `json:"error,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// versionDrift 对象在某个版本与存储版本之间的字段差异
+type versionDrift struct {
+	Version string             `json:"version"`
+	Fields  []diff.FieldChange `json:"fields"`
+}
+
+// roundTripResult 版本 -> 存储版本 -> 版本往返后丢失或变化的字段
+type roundTripResult struct {
+	Version    string             `json:"version"`
+	LostFields []string           `json:"lostFields"`
+	Changed    []diff.FieldChange `json:"changed,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// objectConversion 单个对象的转换检查结果
+type objectConversion struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Versions  []versionResult   `json:"versions"`
+	Drift     []versionDrift    `json:"drift"`
+	RoundTrip []roundTripResult `json:"roundTrip,omitempty"`
+}
+
+// inspectCRDConversion 在每个服务版本下读取同一对象，报告转换错误、
+// 与存储版本的字段差异，以及status.storedVersions中待迁移的版本，只读取不写入。
+func (s *Server) inspectCRDConversion(c *gin.Context) {
+	if !s.requireLiveCluster(c) {
+		return
+	}
+	s.writeConversionReport(c, false)
+}
+
+// roundTripCRDConversion 在inspectCRDConversion的基础上，对每个非存储版本执行dryRun更新
+// （版本 -> 存储版本 -> 版本）报告往返转换丢失的字段。dryRun更新同样经过准入Webhook，
+// 因此与其他写操作一样使用POST并受只读开关和跨源检查约束。
+func (s *Server) roundTripCRDConversion(c *gin.Context) {
+	s.writeConversionReport(c, true)
+}
+
+// writeConversionReport 按查询参数选择对象并返回转换检查报告
+func (s *Server) writeConversionReport(c *gin.Context, roundTrip bool) {
+	ctx := c.Request.Context()
+	_, definition, err := s.getCRDDefinition(ctx, c.Param("name"))
+	if err != nil {
+		writeAPIError(c, "get CRD", err)
+		return
+	}
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "5"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	namespace := c.Query("namespace")
+
+	// 选择要检查的对象：指定object时只检查该对象，否则按存储版本抽样
+	var samples []unstructured.Unstructured
+	if objectName := c.Query("object"); objectName != "" {
+		if definition.Namespaced && namespace == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required for namespaced resources"})
+			return
+		}
+		obj, err := s.versionClient(definition, definition.StorageVersion, namespace).Get(ctx, objectName, metav1.GetOptions{})
+		if err != nil {
+			writeAPIError(c, "get", err)
+			return
+		}
+		samples = append(samples, *obj)
+	} else {
+		list, err := s.versionClient(definition, definition.StorageVersion, namespace).List(ctx, metav1.ListOptions{Limit: limit})
+		if err != nil {
+			writeAPIError(c, "list", err)
+			return
+		}
+		samples = list.Items
+	}
+
+	objects := make([]objectConversion, 0, len(samples))
+	for i := range samples {
+		objects = append(objects, s.inspectObjectConversion(ctx, definition, &samples[i], roundTrip))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crd":                   definition.Name,
+		"group":                 definition.Group,
+		"resource":              definition.Plural,
+		"conversionStrategy":    definition.ConversionStrategy,
+		"storageVersion":        definition.StorageVersion,
+		"servedVersions":        definition.ServedVersions,
+		"storedVersions":        definition.StoredVersions,
+		"pendingStoredVersions": definition.pendingStoredVersions(),
+		"objects":               objects,
+	})
+}
+
+// inspectObjectConversion 检查单个对象在各服务版本下的转换结果
+func (s *Server) inspectObjectConversion(ctx context.Context, definition *crdDefinition, stored *unstructured.Unstructured, roundTrip bool) objectConversion {
+	result := objectConversion{
+		Namespace: stored.GetNamespace(),
+		Name:      stored.GetName(),
+		Versions:  []versionResult{},
+		Drift:     []versionDrift{},
+	}
+	options := diff.Options{IgnoredFields: conversionIgnoredFields}
+
+	for _, version := range definition.ServedVersions {
+		client := s.versionClient(definition, version, stored.GetNamespace())
+		obj, err := client.Get(ctx, stored.GetName(), metav1.GetOptions{})
+		if err != nil {
+			result.Versions = append(result.Versions, versionResult{Version: version, Error: err.Error()})
+			continue
+		}
+		result.Versions = append(result.Versions, versionResult{Version: version, OK: true, ResourceVersion: obj.GetResourceVersion()})
+
+		if version == definition.StorageVersion {
+			continue
+		}
+		result.Drift = append(result.Drift, versionDrift{Version: version, Fields: diff.Fields(stored.Object, obj.Object, options)})
+
+		if !roundTrip {
+			continue
+		}
+		result.RoundTrip = append(result.RoundTrip, roundTripVersion(ctx, client, obj, options))
+	}
+
+	return result
+}
+
+// roundTripVersion 以dryRun方式在该版本下原样更新对象，服务器会转换到存储版本再转换回来，
+// 比较返回结果与原对象即可发现往返转换中丢失的字段
+func roundTripVersion(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured, options diff.Options) roundTripResult {
+	result := roundTripResult{Version: obj.GroupVersionKind().Version, LostFields: []string{}}
+
+	updated, err := client.Update(ctx, obj.DeepCopy(), metav1.UpdateOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: defaultFieldManager,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, change := range diff.Fields(obj.Object, updated.Object, options) {
+		if change.Type == diff.FieldRemoved {
+			result.LostFields = append(result.LostFields, change.Path)
+		} else {
+			result.Changed = append(result.Changed, change)
+		}
+	}
+	return result
+}
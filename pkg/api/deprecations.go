@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/deprecation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// lastAppliedAnnotation kubectl apply记录上次应用配置的注解
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// maxReportedObjects 每个API在报告中列出的对象数上限
+const maxReportedObjects = 100
+
+// deprecatedObject 升级后会受影响的对象
+type deprecatedObject struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Reason 判定依据：served（没有替代版本，对象本身不可用）、lastApplied（清单使用旧版本）、
+	// managedFields（有客户端通过旧版本写入）、manifest（离线转储中以旧版本保存）
+	Reason string `json:"reason"`
+}
+
+// deprecatedAPIReport 单个弃用API的报告
+type deprecatedAPIReport struct {
+	Group        string             `json:"group"`
+	Version      string             `json:"version"`
+	Resource     string             `json:"resource"`
+	DeprecatedIn string             `json:"deprecatedIn,omitempty"`
+	RemovedIn    string             `json:"removedIn"`
+	Replacement  string             `json:"replacement,omitempty"`
+	Served       bool               `json:"served"`
+	ObjectCount  int                `json:"objectCount"`
+	Objects      []deprecatedObject `json:"objects"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// getDeprecationReport 列出升级到targetVersion后会失效的API版本和对象
+// 集群当前提供的弃用版本会被列出；对象按last-applied注解或managedFields中记录的apiVersion判断是否仍在使用旧版本，
+// 没有替代版本的API（如PodSecurityPolicy）中的所有对象都会失效。
+func (s *Server) getDeprecationReport(c *gin.Context) {
+	targetVersion := c.Query("targetVersion")
+	if targetVersion == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetVersion is required, e.g. targetVersion=1.29"})
+		return
+	}
+	target, err := deprecation.ParseRelease(targetVersion)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 只报告集群当前版本之后移除的API，当前版本已移除的API早已不再使用；版本未知时不设下限
+	response := gin.H{"targetVersion": target.String()}
+	var current deprecation.Release
+	if s.offlineCache == nil {
+		if info, err := s.discoveryClient.ServerVersion(); err == nil {
+			response["clusterVersion"] = info.GitVersion
+			if release, err := deprecation.ParseRelease(info.GitVersion); err == nil {
+				current = release
+			}
+		}
+	}
+
+	ctx := c.Request.Context()
+	served := make(map[string]map[string]bool)
+	reports := []deprecatedAPIReport{}
+	affectedObjects := 0
+	servedAPIs := 0
+
+	for _, entry := range deprecation.Default().RemovedBetween(current, target) {
+		for _, resource := range entry.Resources {
+			report := deprecatedAPIReport{
+				Group:        entry.Group,
+				Version:      entry.Version,
+				Resource:     resource,
+				DeprecatedIn: entry.DeprecatedIn,
+				RemovedIn:    entry.RemovedIn,
+				Replacement:  entry.Replacement,
+				Objects:      []deprecatedObject{},
+			}
+
+			if s.offlineCache != nil {
+				s.offlineDeprecationObjects(&entry, resource, &report)
+			} else {
+				report.Served = s.isServed(served, entry.GroupVersion(), resource)
+				s.liveDeprecationObjects(ctx, served, &entry, resource, &report)
+			}
+
+			// 集群不提供且没有对象引用的API不影响升级
+			if !report.Served && report.ObjectCount == 0 && report.Error == "" {
+				continue
+			}
+			if report.Served {
+				servedAPIs++
+			}
+			affectedObjects += report.ObjectCount
+			reports = append(reports, report)
+		}
+	}
+
+	response["apis"] = reports
+	response["summary"] = gin.H{
+		"apis":            len(reports),
+		"servedAPIs":      servedAPIs,
+		"affectedObjects": affectedObjects,
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// isServed 检查集群是否提供指定组版本中的资源，结果按组版本缓存
+func (s *Server) isServed(served map[string]map[string]bool, groupVersion, resource string) bool {
+	resources, ok := served[groupVersion]
+	if !ok {
+		resources = make(map[string]bool)
+		if list, err := s.discoveryClient.ServerResourcesForGroupVersion(groupVersion); err == nil {
+			for _, apiResource := range list.APIResources {
+				resources[apiResource.Name] = true
+			}
+		}
+		served[groupVersion] = resources
+	}
+	return resources[resource]
+}
+
+// liveDeprecationObjects 从集群列出受影响的对象：优先通过替代版本读取，没有替代版本时通过弃用版本读取
+func (s *Server) liveDeprecationObjects(ctx context.Context, served map[string]map[string]bool, entry *deprecation.Entry, resource string, report *deprecatedAPIReport) {
+	listVersion := entry.GroupVersion()
+	if entry.Replacement != "" && s.isServed(served, entry.Replacement, resource) {
+		listVersion = entry.Replacement
+	} else if !report.Served {
+		return
+	}
+
+	gv, err := schema.ParseGroupVersion(listVersion)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+
+	client := s.dynamicClient.Resource(gv.WithResource(resource))
+	options := metav1.ListOptions{Limit: 500}
+	for {
+		list, err := client.List(ctx, options)
+		if err != nil {
+			report.Error = err.Error()
+			return
+		}
+		for i := range list.Items {
+			if reason := deprecatedUsage(&list.Items[i], entry, report.Served); reason != "" {
+				report.addObject(&list.Items[i], reason)
+			}
+		}
+		if options.Continue = list.GetContinue(); options.Continue == "" {
+			return
+		}
+	}
+}
+
+// offlineDeprecationObjects 离线模式：转储中以弃用版本保存的对象都会受影响
+func (s *Server) offlineDeprecationObjects(entry *deprecation.Entry, resource string, report *deprecatedAPIReport) {
+	gv := schema.GroupVersion{Group: entry.Group, Version: entry.Version}
+	objects, err := s.offlineCache.GetObjects(gv.WithResource(resource), "")
+	if err != nil || len(objects) == 0 {
+		return
+	}
+
+	report.Served = true
+	for _, obj := range objects {
+		report.addObject(obj, "manifest")
+	}
+}
+
+// addObject 记录受影响的对象，超过上限时只计数
+func (r *deprecatedAPIReport) addObject(obj *unstructured.Unstructured, reason string) {
+	r.ObjectCount++
+	if len(r.Objects) < maxReportedObjects {
+		r.Objects = append(r.Objects, deprecatedObject{Namespace: obj.GetNamespace(), Name: obj.GetName(), Reason: reason})
+	}
+}
+
+// deprecatedUsage 判断对象是否仍依赖弃用版本，返回判定依据，未使用时返回空
+func deprecatedUsage(obj *unstructured.Unstructured, entry *deprecation.Entry, served bool) string {
+	deprecatedVersion := entry.GroupVersion()
+
+	if entry.Replacement == "" && served {
+		return "served"
+	}
+
+	if lastApplied := obj.GetAnnotations()[lastAppliedAnnotation]; lastApplied != "" {
+		var applied struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if err := json.Unmarshal([]byte(lastApplied), &applied); err == nil && applied.APIVersion == deprecatedVersion {
+			return "lastApplied"
+		}
+	}
+
+	for _, managed := range obj.GetManagedFields() {
+		if strings.TrimSpace(managed.APIVersion) == deprecatedVersion {
+			return "managedFields"
+		}
+	}
+	return ""
+}
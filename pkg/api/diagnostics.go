@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+)
+
+// apiServicesGVR APIService资源，使用动态客户端读取以避免引入kube-aggregator客户端
+var apiServicesGVR = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// DiscoveryFailure 发现失败的组版本
+type DiscoveryFailure struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Error   string `json:"error"`
+}
+
+// ServiceHealth 后端Service的健康状态
+type ServiceHealth struct {
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`
+	Port           int32  `json:"port,omitempty"`
+	Exists         bool   `json:"exists"`
+	ReadyEndpoints int    `json:"readyEndpoints"`
+	Error          string `json:"error,omitempty"`
+}
+
+// Healthy 检查Service是否存在且有就绪的端点
+func (h *ServiceHealth) Healthy() bool {
+	return h.Exists && h.ReadyEndpoints > 0
+}
+
+// APIServiceDiagnostic APIService可用性
+type APIServiceDiagnostic struct {
+	Name      string         `json:"name"`
+	Group     string         `json:"group"`
+	Version   string         `json:"version"`
+	Local     bool           `json:"local"`
+	Available bool           `json:"available"`
+	Reason    string         `json:"reason,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Service   *ServiceHealth `json:"service,omitempty"`
+}
+
+// WebhookDiagnostic 准入webhook目标的健康状态
+type WebhookDiagnostic struct {
+	Configuration string         `json:"configuration"`
+	Type          string         `json:"type"`
+	Name          string         `json:"name"`
+	FailurePolicy string         `json:"failurePolicy,omitempty"`
+	URL           string         `json:"url,omitempty"`
+	Service       *ServiceHealth `json:"service,omitempty"`
+	Healthy       bool           `json:"healthy"`
+}
+
+// setDiscoveryFailures 记录最近一次发现的失败组版本，err为nil时清空
+func (s *Server) setDiscoveryFailures(err *discovery.ErrGroupDiscoveryFailed) {
+	var failures []DiscoveryFailure
+	if err != nil {
+		for gv, groupErr := range err.Groups {
+			failures = append(failures, DiscoveryFailure{Group: gv.Group, Version: gv.Version, Error: groupErr.Error()})
+		}
+		sort.Slice(failures, func(i, j int) bool {
+			if failures[i].Group != failures[j].Group {
+				return failures[i].Group < failures[j].Group
+			}
+			return failures[i].Version < failures[j].Version
+		})
+	}
+
+	s.discoveryFailuresMutex.Lock()
+	s.discoveryFailures = failures
+	s.discoveryFailuresMutex.Unlock()
+}
+
+// getDiscoveryFailures 返回最近一次发现的失败组版本
+func (s *Server) getDiscoveryFailures() []DiscoveryFailure {
+	s.discoveryFailuresMutex.RLock()
+	defer s.discoveryFailuresMutex.RUnlock()
+	return s.discoveryFailures
+}
+
+// writeResources 返回资源列表，发现失败数通过X-Discovery-Failures头返回；
+// withMetadata=true时返回包含失败详情的对象，默认保持数组格式以兼容前端
+func (s *Server) writeResources(c *gin.Context, resources []Resource) {
+	failures := s.getDiscoveryFailures()
+	c.Header("X-Discovery-Failures", strconv.Itoa(len(failures)))
+
+	if c.Query("withMetadata") != "true" {
+		writeJSONList(c, resources, "", nil)
+		return
+	}
+
+	if failures == nil {
+		failures = []DiscoveryFailure{}
+	}
+	writeJSONList(c, resources, "items", gin.H{
+		"metadata": gin.H{
+			"discoveryFailures": failures,
+		},
+	})
+}
+
+// getDiagnostics 诊断发现失败的常见原因：不可用的APIService和后端不可用的准入webhook
+func (s *Server) getDiagnostics(c *gin.Context) {
+	if !s.requireLiveCluster(c) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	services := make(map[string]*ServiceHealth)
+
+	apiServices, err := s.diagnoseAPIServices(ctx, services)
+	if err != nil {
+		klog.Errorf("Failed to diagnose API services: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhooks, err := s.diagnoseWebhooks(ctx, services)
+	if err != nil {
+		klog.Errorf("Failed to diagnose webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	unavailableAPIServices := 0
+	for _, apiService := range apiServices {
+		if !apiService.Available {
+			unavailableAPIServices++
+		}
+	}
+	unhealthyWebhooks := 0
+	for _, webhook := range webhooks {
+		if !webhook.Healthy {
+			unhealthyWebhooks++
+		}
+	}
+
+	failures := s.getDiscoveryFailures()
+	if failures == nil {
+		failures = []DiscoveryFailure{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"discoveryFailures": failures,
+		"apiServices":       apiServices,
+		"webhooks":          webhooks,
+		"summary": gin.H{
+			"discoveryFailures":      len(failures),
+			"apiServices":            len(apiServices),
+			"unavailableAPIServices": unavailableAPIServices,
+			"webhooks":               len(webhooks),
+			"unhealthyWebhooks":      unhealthyWebhooks,
+		},
+	})
+}
+
+// diagnoseAPIServices 列出APIService的Available条件和后端Service状态
+func (s *Server) diagnoseAPIServices(ctx context.Context, services map[string]*ServiceHealth) ([]APIServiceDiagnostic, error) {
+	list, err := s.dynamicClient.Resource(apiServicesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apiservices: %v", err)
+	}
+
+	result := make([]APIServiceDiagnostic, 0, len(list.Items))
+	for _, item := range list.Items {
+		diagnostic := APIServiceDiagnostic{Name: item.GetName()}
+		diagnostic.Group, _, _ = unstructured.NestedString(item.Object, "spec", "group")
+		diagnostic.Version, _, _ = unstructured.NestedString(item.Object, "spec", "version")
+
+		conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+		for _, raw := range conditions {
+			condition, ok := raw.(map[string]interface{})
+			if !ok || condition["type"] != "Available" {
+				continue
+			}
+			diagnostic.Available = condition["status"] == "True"
+			diagnostic.Reason, _ = condition["reason"].(string)
+			diagnostic.Message, _ = condition["message"].(string)
+		}
+
+		// 没有spec.service的APIService由kube-apiserver本地提供
+		namespace, _, _ := unstructured.NestedString(item.Object, "spec", "service", "namespace")
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "service", "name")
+		if name == "" {
+			diagnostic.Local = true
+		} else {
+			port, _, _ := unstructured.NestedInt64(item.Object, "spec", "service", "port")
+			diagnostic.Service = s.serviceHealth(ctx, services, namespace, name, int32(port))
+		}
+
+		result = append(result, diagnostic)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// diagnoseWebhooks 列出Mutating/ValidatingWebhookConfiguration的目标和后端Service状态
+func (s *Server) diagnoseWebhooks(ctx context.Context, services map[string]*ServiceHealth) ([]WebhookDiagnostic, error) {
+	admission := s.clientset.AdmissionregistrationV1()
+
+	mutating, err := admission.MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhook configurations: %v", err)
+	}
+	validating, err := admission.ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validating webhook configurations: %v", err)
+	}
+
+	var result []WebhookDiagnostic
+	for _, config := range mutating.Items {
+		for _, webhook := range config.Webhooks {
+			result = append(result, s.diagnoseWebhook(ctx, services, config.Name, "mutating", webhook.Name, webhook.FailurePolicy, webhook.ClientConfig))
+		}
+	}
+	for _, config := range validating.Items {
+		for _, webhook := range config.Webhooks {
+			result = append(result, s.diagnoseWebhook(ctx, services, config.Name, "validating", webhook.Name, webhook.FailurePolicy, webhook.ClientConfig))
+		}
+	}
+
+	if result == nil {
+		result = []WebhookDiagnostic{}
+	}
+	return result, nil
+}
+
+// diagnoseWebhook 检查单个webhook的目标，外部URL无法从集群内验证，视为健康
+func (s *Server) diagnoseWebhook(ctx context.Context, services map[string]*ServiceHealth, configuration, webhookType, name string,
+	failurePolicy *admissionregistrationv1.FailurePolicyType, clientConfig admissionregistrationv1.WebhookClientConfig) WebhookDiagnostic {
+	diagnostic := WebhookDiagnostic{
+		Configuration: configuration,
+		Type:          webhookType,
+		Name:          name,
+		Healthy:       true,
+	}
+	if failurePolicy != nil {
+		diagnostic.FailurePolicy = string(*failurePolicy)
+	}
+
+	if clientConfig.URL != nil {
+		diagnostic.URL = *clientConfig.URL
+	}
+	if ref := clientConfig.Service; ref != nil {
+		var port int32
+		if ref.Port != nil {
+			port = *ref.Port
+		}
+		diagnostic.Service = s.serviceHealth(ctx, services, ref.Namespace, ref.Name, port)
+		diagnostic.Healthy = diagnostic.Service.Healthy()
+	}
+
+	return diagnostic
+}
+
+// serviceHealth 检查Service是否存在及其EndpointSlice中的就绪端点数，结果按Service缓存
+func (s *Server) serviceHealth(ctx context.Context, services map[string]*ServiceHealth, namespace, name string, port int32) *ServiceHealth {
+	key := namespace + "/" + name
+	if cached, ok := services[key]; ok {
+		health := *cached
+		health.Port = port
+		return &health
+	}
+
+	health := &ServiceHealth{Namespace: namespace, Name: name}
+	services[key] = health
+
+	if _, err := s.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			health.Error = err.Error()
+		}
+	} else {
+		health.Exists = true
+
+		slices, err := s.clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: discoveryv1.LabelServiceName + "=" + name,
+		})
+		if err != nil {
+			health.Error = err.Error()
+		} else {
+			for _, slice := range slices.Items {
+				for _, endpoint := range slice.Endpoints {
+					// Ready为空时按就绪处理，与kube-proxy一致
+					if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+						health.ReadyEndpoints++
+					}
+				}
+			}
+		}
+	}
+
+	result := *health
+	result.Port = port
+	return &result
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// maxLogLineSize 单行日志的最大长度，超长的行会被截断为多行
+const maxLogLineSize = 256 * 1024
+
+// parsePodLogOptions 解析日志查询参数
+func parsePodLogOptions(c *gin.Context) (*corev1.PodLogOptions, error) {
+	options := &corev1.PodLogOptions{
+		Container: c.Query("container"),
+		Follow:    c.Query("follow") == "true",
+		Previous:  c.Query("previous") == "true",
+	}
+
+	if value := c.Query("tailLines"); value != "" {
+		tailLines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || tailLines < 0 {
+			return nil, fmt.Errorf("invalid tailLines %q", value)
+		}
+		options.TailLines = &tailLines
+	}
+	if value := c.Query("sinceSeconds"); value != "" {
+		sinceSeconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || sinceSeconds <= 0 {
+			return nil, fmt.Errorf("invalid sinceSeconds %q", value)
+		}
+		options.SinceSeconds = &sinceSeconds
+	}
+	if options.Follow && options.Previous {
+		return nil, fmt.Errorf("follow and previous cannot be used together")
+	}
+
+	return options, nil
+}
+
+// getPodLogs 读取Pod容器日志
+// follow=true或Accept为text/event-stream时以SSE逐行推送，否则返回纯文本。
+// 上游请求使用不设整体超时的客户端并绑定客户端请求的上下文，客户端断开后立即停止；逐行写入并刷新，
+// 客户端读取慢时写入阻塞，不会在内存中堆积日志。
+func (s *Server) getPodLogs(c *gin.Context) {
+	if !s.requireLiveCluster(c) {
+		return
+	}
+
+	options, err := parsePodLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	ctx := c.Request.Context()
+
+	stream, err := s.streamClientset.CoreV1().Pods(namespace).GetLogs(name, options).Stream(ctx)
+	if err != nil {
+		writeAPIError(c, "get logs for", err)
+		return
+	}
+	defer stream.Close()
+
+	if !options.Follow && !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, stream); err != nil && ctx.Err() == nil {
+			klog.Warningf("Failed to copy logs for pod %s/%s: %v", namespace, name, err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+		c.SSEvent("log", scanner.Text())
+		c.Writer.Flush()
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		klog.Warningf("Log stream for pod %s/%s ended with error: %v", namespace, name, err)
+		c.SSEvent("error", err.Error())
+	} else {
+		c.SSEvent("end", "")
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/migration"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// startMigrationRequest 开始迁移请求
+type startMigrationRequest struct {
+	migration.Options
+	// Resume 从上次保存的进度继续
+	Resume bool `json:"resume"`
+}
+
+// requireMigrator 检查迁移器是否可用（离线模式下不可用）
+func (s *Server) requireMigrator(c *gin.Context) bool {
+	if !s.requireLiveCluster(c) {
+		return false
+	}
+	if s.migrator == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "migrator is not initialized"})
+		return false
+	}
+	return true
+}
+
+// migrationTarget 根据CRD定义生成迁移目标
+func migrationTarget(definition *crdDefinition) migration.Target {
+	return migration.Target{
+		CRD:            definition.Name,
+		Group:          definition.Group,
+		Resource:       definition.Plural,
+		StorageVersion: definition.StorageVersion,
+		Namespaced:     definition.Namespaced,
+	}
+}
+
+// listMigrations 列出本次运行以来的迁移
+func (s *Server) listMigrations(c *gin.Context) {
+	if !s.requireMigrator(c) {
+		return
+	}
+	writeJSONList(c, s.migrator.List(), "", nil)
+}
+
+// getMigration 返回CRD的迁移计划和进度
+// API服务器不暴露单个对象的存储编码版本，status.storedVersions中有旧版本时，所有对象都是候选对象。
+func (s *Server) getMigration(c *gin.Context) {
+	if !s.requireMigrator(c) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	_, definition, err := s.getCRDDefinition(ctx, c.Param("name"))
+	if err != nil {
+		writeAPIError(c, "get CRD", err)
+		return
+	}
+
+	pending := definition.pendingStoredVersions()
+	response := gin.H{
+		"crd":                   definition.Name,
+		"storageVersion":        definition.StorageVersion,
+		"storedVersions":        definition.StoredVersions,
+		"pendingStoredVersions": pending,
+		"migrationNeeded":       len(pending) > 0,
+	}
+
+	// 只取一个对象，通过remainingItemCount估算候选对象数
+	list, err := s.dynamicClient.Resource(definition.gvr(definition.StorageVersion)).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		writeAPIError(c, "list", err)
+		return
+	}
+	candidates := int64(len(list.Items))
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		candidates += *remaining
+	}
+	if len(pending) > 0 {
+		response["candidateObjects"] = candidates
+	} else {
+		response["candidateObjects"] = 0
+	}
+
+	if state, ok := s.migrator.Get(definition.Name); ok {
+		response["migration"] = state
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// startMigration 开始（或恢复）按存储版本限速重写所有对象
+func (s *Server) startMigration(c *gin.Context) {
+	if !s.requireMigrator(c) {
+		return
+	}
+
+	req := startMigrationRequest{Options: migration.DefaultOptions()}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	_, definition, err := s.getCRDDefinition(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		writeAPIError(c, "get CRD", err)
+		return
+	}
+
+	state, err := s.migrator.Start(migrationTarget(definition), req.Options, req.Resume)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, state)
+}
+
+// cancelMigration 取消正在运行的迁移，进度会被保存以便恢复
+func (s *Server) cancelMigration(c *gin.Context) {
+	if !s.requireMigrator(c) {
+		return
+	}
+
+	name := c.Param("name")
+	if !s.migrator.Cancel(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no running migration for " + name})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cancelled": name})
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// defaultFieldManager 服务端应用和写操作默认使用的字段管理器
+const defaultFieldManager = "crds-objects-browser"
+
+// maxMutationBodySize 写操作请求体大小上限
+const maxMutationBodySize = 3 * 1024 * 1024
+
+// patchContentTypes 支持的补丁类型
+var patchContentTypes = map[string]types.PatchType{
+	"application/json-patch+json":            types.JSONPatchType,
+	"application/merge-patch+json":           types.MergePatchType,
+	"application/strategic-merge-patch+json": types.StrategicMergePatchType,
+	"application/apply-patch+yaml":           types.ApplyPatchType,
+}
+
+// writeGuard 只读模式下拒绝所有写操作
+func (s *Server) writeGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.options.ReadOnly {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "server is running in read-only mode"})
+			return
+		}
+		if !s.requireLiveCluster(c) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// readMutationBody 读取写操作请求体，超出maxMutationBodySize时返回413，不截断后继续处理
+func readMutationBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxMutationBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge,
+				gin.H{"error": fmt.Sprintf("request body exceeds %d bytes", maxMutationBodySize)})
+		} else {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// isSafeMethod 检查请求方法是否为只读方法
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameOrigin 检查请求是否来自同源页面
+// 浏览器跨源请求会带Origin或Sec-Fetch-Site头；命令行等非浏览器客户端不带这两个头，视为同源
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
+
+// resourceClient 返回指定资源（和命名空间）的动态客户端
+func (s *Server) resourceClient(c *gin.Context) (dynamic.ResourceInterface, error) {
+	gvr := gvrFromParams(c)
+	namespace := c.Query("namespace")
+
+	namespaced, err := s.isNamespacedResourceCached(gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !namespaced {
+		return s.dynamicClient.Resource(gvr), nil
+	}
+	if namespace == "" || namespace == "all" {
+		return nil, fmt.Errorf("namespace is required for namespaced resource %s", gvr.String())
+	}
+	return s.dynamicClient.Resource(gvr).Namespace(namespace), nil
+}
+
+// parseDryRun 解析dryRun参数，只支持All
+func parseDryRun(c *gin.Context) ([]string, error) {
+	switch dryRun := c.Query("dryRun"); dryRun {
+	case "":
+		return nil, nil
+	case metav1.DryRunAll:
+		return []string{metav1.DryRunAll}, nil
+	default:
+		return nil, fmt.Errorf("invalid dryRun value %q, only %q is supported", dryRun, metav1.DryRunAll)
+	}
+}
+
+// updateResourceObject 使用完整对象更新资源，必须携带resourceVersion以检测冲突
+func (s *Server) updateResourceObject(c *gin.Context) {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, ok := readMutationBody(c)
+	if !ok {
+		return
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid object: %v", err)})
+		return
+	}
+	if obj.GetName() != c.Param("name") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("object name %q does not match %q", obj.GetName(), c.Param("name"))})
+		return
+	}
+	if obj.GetResourceVersion() == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata.resourceVersion is required to detect conflicts"})
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := client.Update(c.Request.Context(), obj, metav1.UpdateOptions{
+		DryRun:       dryRun,
+		FieldManager: c.DefaultQuery("fieldManager", defaultFieldManager),
+	})
+	if err != nil {
+		writeAPIError(c, "update", err)
+		return
+	}
+
+	klog.Infof("Updated %s %s/%s (dryRun=%v)", gvrFromParams(c).String(), obj.GetNamespace(), obj.GetName(), dryRun != nil)
+	c.JSON(http.StatusOK, result.Object)
+}
+
+// patchResourceObject 按Content-Type应用JSON、合并、策略合并补丁或服务端应用
+func (s *Server) patchResourceObject(c *gin.Context) {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := strings.TrimSpace(strings.Split(c.GetHeader("Content-Type"), ";")[0])
+	patchType, ok := patchContentTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported patch content type %q", contentType)})
+		return
+	}
+
+	body, ok := readMutationBody(c)
+	if !ok {
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options := metav1.PatchOptions{
+		DryRun:       dryRun,
+		FieldManager: c.DefaultQuery("fieldManager", defaultFieldManager),
+	}
+	if patchType == types.ApplyPatchType {
+		// 服务端应用：force=true时接管其他字段管理器的冲突字段
+		force := c.Query("force") == "true"
+		options.Force = &force
+	}
+
+	name := c.Param("name")
+	result, err := client.Patch(c.Request.Context(), name, patchType, body, options)
+	if err != nil {
+		writeAPIError(c, "patch", err)
+		return
+	}
+
+	klog.Infof("Patched %s %s/%s with %s (dryRun=%v)", gvrFromParams(c).String(), c.Query("namespace"), name, patchType, dryRun != nil)
+	c.JSON(http.StatusOK, result.Object)
+}
+
+// deleteResourceObject 删除资源对象，可通过resourceVersion参数设置前置条件
+func (s *Server) deleteResourceObject(c *gin.Context) {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options := metav1.DeleteOptions{DryRun: dryRun}
+	if resourceVersion := c.Query("resourceVersion"); resourceVersion != "" {
+		options.Preconditions = &metav1.Preconditions{ResourceVersion: &resourceVersion}
+	}
+	if policy := c.Query("propagationPolicy"); policy != "" {
+		propagation := metav1.DeletionPropagation(policy)
+		options.PropagationPolicy = &propagation
+	}
+
+	name := c.Param("name")
+	if err := client.Delete(c.Request.Context(), name, options); err != nil {
+		writeAPIError(c, "delete", err)
+		return
+	}
+
+	klog.Infof("Deleted %s %s/%s (dryRun=%v)", gvrFromParams(c).String(), c.Query("namespace"), name, dryRun != nil)
+	c.JSON(http.StatusOK, gin.H{"deleted": name, "dryRun": dryRun != nil})
+}
+
+// writeAPIError 将Kubernetes API错误（如409冲突）按原状态码返回
+func writeAPIError(c *gin.Context, action string, err error) {
+	code := http.StatusInternalServerError
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Code != 0 {
+		code = int(status.Status().Code)
+	}
+
+	klog.Warningf("Failed to %s object: %v", action, err)
+	c.JSON(code, gin.H{
+		"error":  err.Error(),
+		"reason": string(apierrors.ReasonForError(err)),
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/capacity"
+	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var nodesGVR = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// getNodes 返回所有节点的Pod资源请求与可分配量对比、污点、状况和cordon状态
+// pods=true时包含每个节点上的Pod列表
+func (s *Server) getNodes(c *gin.Context) {
+	nodes, err := s.getCachedObjects(c.Request.Context(), nodesGVR, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	withPods := c.Query("pods") == "true"
+	views := make([]capacity.NodeView, 0, len(nodes))
+	for _, obj := range nodes {
+		view, err := s.nodeView(c.Request.Context(), obj, withPods)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+
+	// pods=true时每个节点带完整的Pod列表，逐个编码，避免在内存中构建整个响应体
+	writeJSONList(c, views, "", nil)
+}
+
+// getNode 返回单个节点的视图，包括其上的Pod
+func (s *Server) getNode(c *gin.Context) {
+	name := c.Param("name")
+	nodes, err := s.getCachedObjects(c.Request.Context(), nodesGVR, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, obj := range nodes {
+		if obj.GetName() != name {
+			continue
+		}
+		view, err := s.nodeView(c.Request.Context(), obj, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, view)
+		return
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "node " + name + " not found"})
+}
+
+// nodeView 通过spec.nodeName索引读取节点上的Pod并生成节点视图
+func (s *Server) nodeView(ctx context.Context, obj *unstructured.Unstructured, withPods bool) (capacity.NodeView, error) {
+	node, err := capacity.ToNode(obj)
+	if err != nil {
+		return capacity.NodeView{}, err
+	}
+
+	pods, err := s.strategyManager.GetObjectsByIndex(ctx, podsGVR, true, informer.PodNodeNameIndex, node.Name)
+	if err != nil {
+		return capacity.NodeView{}, err
+	}
+
+	return capacity.BuildNodeView(node, pods, withPods), nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"github.com/jicki/crds-objects-browser/pkg/offline"
+)
+
+// initOfflineBackend 从集群转储加载数据，不连接任何集群
+func (s *Server) initOfflineBackend(source string) error {
+	cache, err := offline.Load(source)
+	if err != nil {
+		return fmt.Errorf("failed to load offline source: %v", err)
+	}
+
+	// 离线数据不会变化，无需自动清理
+	strategy := informer.DefaultStrategy()
+	strategy.AutoCleanupEnabled = false
+
+	s.offlineCache = cache
+	s.strategyManager = informer.NewStrategyManager(cache, strategy)
+	s.initPolicyEngine(cache)
+
+	klog.Infof("Running in offline mode with cluster dump from %s", source)
+	return nil
+}
+
+// offlineResources 返回离线转储合成的资源列表
+func (s *Server) offlineResources() []Resource {
+	var resources []Resource
+	for _, info := range s.offlineCache.Resources() {
+		resources = append(resources, Resource{
+			Group:      info.Group,
+			Version:    info.Version,
+			Name:       info.Name,
+			Kind:       info.Kind,
+			Namespaced: info.Namespaced,
+		})
+	}
+
+	// 与在线发现保持相同的排序
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources
+}
+
+// isNamespacedOfflineResource 从离线资源列表中检查资源是否为命名空间资源
+func (s *Server) isNamespacedOfflineResource(gvr schema.GroupVersionResource) (bool, error) {
+	for _, info := range s.offlineCache.Resources() {
+		if info.Group == gvr.Group && info.Version == gvr.Version && info.Name == gvr.Resource {
+			return info.Namespaced, nil
+		}
+	}
+	return false, fmt.Errorf("resource %s not found", gvr.String())
+}
+
+// requireLiveCluster 离线模式下拒绝需要访问集群的请求，返回false表示已写入响应
+func (s *Server) requireLiveCluster(c *gin.Context) bool {
+	if s.offlineCache == nil {
+		return true
+	}
+
+	c.JSON(http.StatusNotImplemented, gin.H{"error": "not available in offline mode"})
+	return false
+}
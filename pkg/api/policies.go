@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/policy"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// initPolicyEngine 创建策略引擎，在线模式下通过Informer事件增量更新结果
+func (s *Server) initPolicyEngine(source policy.ObjectSource) {
+	if s.snapshotStore != nil {
+		s.policyEngine = policy.NewEngine(source, s.snapshotStore)
+	} else {
+		s.policyEngine = policy.NewEngine(source, nil)
+	}
+
+	if s.informerManager != nil {
+		s.informerManager.AddEventListener(s.policyEngine.OnEvent)
+	}
+}
+
+// restorePolicies 重新应用本地存储中保存的策略
+func (s *Server) restorePolicies() {
+	policies, err := s.policyEngine.LoadPolicies()
+	if err != nil {
+		klog.Warningf("Failed to load saved policies: %v", err)
+		return
+	}
+
+	for _, p := range policies {
+		if err := s.applyPolicy(p); err != nil {
+			klog.Warningf("Failed to restore policy %s: %v", p.Name, err)
+		}
+	}
+}
+
+// applyPolicy 固定策略涉及资源的Informer后应用策略，替换旧策略时释放旧资源
+func (s *Server) applyPolicy(p policy.Policy) error {
+	if err := policy.Validate(p); err != nil {
+		return err
+	}
+
+	var pinned []schema.GroupVersionResource
+	for _, resource := range p.Resources {
+		gvr := resource.GroupVersionResource()
+		namespaced, err := s.isNamespacedResourceCached(gvr)
+		if err != nil {
+			s.unpinResources(pinned)
+			return err
+		}
+		if err := s.strategyManager.Pin(gvr, namespaced); err != nil {
+			s.unpinResources(pinned)
+			return err
+		}
+		pinned = append(pinned, gvr)
+	}
+
+	previous, replaced := s.policyEngine.Get(p.Name)
+	if err := s.policyEngine.Apply(p); err != nil {
+		s.unpinResources(pinned)
+		return err
+	}
+
+	if replaced {
+		for _, resource := range previous.Resources {
+			s.strategyManager.Unpin(resource.GroupVersionResource())
+		}
+	}
+	return nil
+}
+
+// unpinResources 取消固定资源
+func (s *Server) unpinResources(resources []schema.GroupVersionResource) {
+	for _, gvr := range resources {
+		s.strategyManager.Unpin(gvr)
+	}
+}
+
+// listPolicies 列出所有策略及其违反情况汇总
+func (s *Server) listPolicies(c *gin.Context) {
+	writeJSONList(c, s.policyEngine.List(), "", nil)
+}
+
+// putPolicy 创建或替换策略，请求体为policy.Policy，名称取自路径
+func (s *Server) putPolicy(c *gin.Context) {
+	var p policy.Policy
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p.Name = c.Param("name")
+
+	if err := s.applyPolicy(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, _ := s.policyEngine.Report(p.Name, "", true)
+	c.JSON(http.StatusOK, report.Summary)
+}
+
+// deletePolicy 删除策略
+func (s *Server) deletePolicy(c *gin.Context) {
+	name := c.Param("name")
+	resources, ok := s.policyEngine.Delete(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "policy " + name + " not found"})
+		return
+	}
+
+	s.unpinResources(resources)
+	c.JSON(http.StatusOK, gin.H{"deleted": name})
+}
+
+// getPolicyReport 返回策略的违反报告，all=true时包含通过检查的对象
+func (s *Server) getPolicyReport(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Query("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+
+	report, ok := s.policyEngine.Report(name, namespace, c.Query("all") != "true")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "policy " + name + " not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
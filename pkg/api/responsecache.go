@@ -0,0 +1,254 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jicki/crds-objects-browser/pkg/flight"
+	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// responseCache 合并并发的相同请求，并在有界的LRU中缓存结果
+// 条目按TTL过期，Informer事件到达时按标签（GVR）失效；缓存的值与Informer缓存共享对象，只读
+type responseCache struct {
+	maxEntries int
+	ttl        time.Duration
+	group      flight.Group[interface{}]
+	// etagPrefix 区分进程实例，重启后旧的ETag不会命中
+	etagPrefix string
+
+	mutex   sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+	// generations 每个标签的失效次数，组成ETag并丢弃计算期间已失效的结果
+	generations map[string]uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	coalesced atomic.Int64
+}
+
+// responseEntry 缓存条目
+type responseEntry struct {
+	key     string
+	tag     string
+	value   interface{}
+	etag    string
+	expires time.Time
+}
+
+// responseResult singleflight共享的计算结果
+type responseResult struct {
+	value interface{}
+	etag  string
+}
+
+// ResponseCacheStats 响应缓存统计
+type ResponseCacheStats struct {
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"maxEntries"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	Coalesced  int64   `json:"coalesced"`
+	HitRate    float64 `json:"hitRate"`
+}
+
+// newResponseCache 创建最多缓存maxEntries个结果、每个结果保留ttl的响应缓存
+func newResponseCache(maxEntries int, ttl time.Duration) *responseCache {
+	return &responseCache{
+		maxEntries:  maxEntries,
+		ttl:         ttl,
+		etagPrefix:  fmt.Sprintf("%x", time.Now().UnixNano()),
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		tags:        make(map[string]map[string]struct{}),
+		generations: make(map[string]uint64),
+	}
+}
+
+// Do 返回key对应的结果和ETag；未命中时相同key的并发请求只执行一次fn，所有请求的ctx都取消后fn的ctx随之取消
+// fn返回cacheable为false的结果（如直接LIST的数据）不缓存，也没有ETag；
+// fn执行期间标签被失效时结果同样不缓存，避免写入已过期的数据
+func (rc *responseCache) Do(ctx context.Context, key, tag string, fn func(ctx context.Context) (interface{}, bool, error)) (interface{}, string, error) {
+	if entry, ok := rc.get(key); ok {
+		rc.hits.Add(1)
+		return entry.value, entry.etag, nil
+	}
+	rc.misses.Add(1)
+
+	v, shared, err := rc.group.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		// 等待前一轮计算期间可能已经写入
+		if entry, ok := rc.get(key); ok {
+			return responseResult{value: entry.value, etag: entry.etag}, nil
+		}
+
+		generation := rc.generation(tag)
+		value, cacheable, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !cacheable || rc.maxEntries <= 0 {
+			return responseResult{value: value}, nil
+		}
+		return responseResult{value: value, etag: rc.add(key, tag, generation, value)}, nil
+	})
+	if shared {
+		rc.coalesced.Add(1)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	result := v.(responseResult)
+	return result.value, result.etag, nil
+}
+
+// Coalesce 只合并相同key的并发调用，不缓存结果
+func (rc *responseCache) Coalesce(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	v, shared, err := rc.group.Do(ctx, key, fn)
+	if shared {
+		rc.coalesced.Add(1)
+	}
+	return v, err
+}
+
+// Invalidate 删除标签下的所有条目，并使之前签发的ETag失效
+func (rc *responseCache) Invalidate(tag string) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.generations[tag]++
+	for key := range rc.tags[tag] {
+		if element, exists := rc.entries[key]; exists {
+			rc.removeElement(element)
+		}
+	}
+}
+
+// OnEvent Informer事件监听器，对象变化时使该资源的缓存响应失效
+func (rc *responseCache) OnEvent(gvr schema.GroupVersionResource, _ informer.EventType, _ *unstructured.Unstructured) {
+	rc.Invalidate(gvr.String())
+}
+
+// OnStop Informer停止监听器，资源被清理或驱逐后改为直接LIST，丢弃之前基于缓存的响应
+func (rc *responseCache) OnStop(gvr schema.GroupVersionResource) {
+	rc.Invalidate(gvr.String())
+}
+
+// Stats 返回响应缓存统计
+func (rc *responseCache) Stats() ResponseCacheStats {
+	rc.mutex.Lock()
+	entries := rc.lru.Len()
+	rc.mutex.Unlock()
+
+	stats := ResponseCacheStats{
+		Entries:    entries,
+		MaxEntries: rc.maxEntries,
+		Hits:       rc.hits.Load(),
+		Misses:     rc.misses.Load(),
+		Coalesced:  rc.coalesced.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// get 返回未过期的条目并标记为最近使用，过期条目直接删除
+func (rc *responseCache) get(key string) (*responseEntry, bool) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	element, exists := rc.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := element.Value.(*responseEntry)
+	if time.Now().After(entry.expires) {
+		rc.removeElement(element)
+		return nil, false
+	}
+	rc.lru.MoveToFront(element)
+	return entry, true
+}
+
+// generation 返回标签当前的失效次数
+func (rc *responseCache) generation(tag string) uint64 {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	return rc.generations[tag]
+}
+
+// add 写入条目并返回ETag，超出容量时淘汰最近最少使用的条目
+// 计算期间标签已失效时不写入，返回空ETag
+func (rc *responseCache) add(key, tag string, generation uint64, value interface{}) string {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if rc.generations[tag] != generation {
+		return ""
+	}
+	if element, exists := rc.entries[key]; exists {
+		rc.removeElement(element)
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	entry := &responseEntry{
+		key:     key,
+		tag:     tag,
+		value:   value,
+		etag:    fmt.Sprintf(`W/"%s-%d-%x"`, rc.etagPrefix, generation, hash.Sum64()),
+		expires: time.Now().Add(rc.ttl),
+	}
+	rc.entries[key] = rc.lru.PushFront(entry)
+	if rc.tags[tag] == nil {
+		rc.tags[tag] = make(map[string]struct{})
+	}
+	rc.tags[tag][key] = struct{}{}
+
+	for rc.lru.Len() > rc.maxEntries {
+		rc.removeElement(rc.lru.Back())
+	}
+	return entry.etag
+}
+
+// removeElement 删除条目，调用方需持有锁
+func (rc *responseCache) removeElement(element *list.Element) {
+	entry := rc.lru.Remove(element).(*responseEntry)
+	delete(rc.entries, entry.key)
+	if keys := rc.tags[entry.tag]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(rc.tags, entry.tag)
+		}
+	}
+}
+
+// notModified 设置ETag响应头，If-None-Match匹配时返回304，调用方不再写入响应体
+// ETag是弱校验器（压缩编码不同时响应体不同），按弱比较匹配
+func notModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
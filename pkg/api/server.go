@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"sort"
@@ -20,15 +21,24 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
+	"github.com/jicki/crds-objects-browser/pkg/audit"
+	"github.com/jicki/crds-objects-browser/pkg/deprecation"
 	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"github.com/jicki/crds-objects-browser/pkg/migration"
+	"github.com/jicki/crds-objects-browser/pkg/offline"
+	"github.com/jicki/crds-objects-browser/pkg/persistence"
+	"github.com/jicki/crds-objects-browser/pkg/policy"
 )
 
 // Server 表示API服务器
 type Server struct {
 	clientset       kubernetes.Interface
+	streamClientset kubernetes.Interface // 不设整体超时，用于跟随日志等流式请求，由请求上下文取消
 	dynamicClient   dynamic.Interface
 	discoveryClient discovery.DiscoveryInterface
 	strategyManager *informer.StrategyManager
+	informerManager *informer.InformerManager
+	offlineCache    *offline.Cache
 	router          *gin.Engine
 	httpServer      *http.Server
 	port            string
@@ -44,13 +54,206 @@ type Server struct {
 	resourcesCacheMutex sync.RWMutex
 	resourcesCacheTTL   time.Duration
 
-	// 请求去重
-	requestDeduplicator map[string]*sync.Mutex
-	deduplicatorMutex   sync.RWMutex
+	// 最近一次发现中失败的组版本
+	discoveryFailures      []DiscoveryFailure
+	discoveryFailuresMutex sync.RWMutex
+
+	// 合并相同请求并缓存响应，Informer事件到达时按GVR失效
+	responseCache *responseCache
+
+	// 快照相关
+	options       *ServerOptions
+	snapshotStore *persistence.Store
+
+	// 审计日志，未配置输出时为nil
+	auditLogger *audit.Logger
+	// 可信认证代理的地址范围，只有来自这些地址的请求才读取用户请求头
+	trustedProxies []*net.IPNet
+
+	// 存储版本迁移器（离线模式下为nil）
+	migrator *migration.Migrator
+
+	// 策略引擎
+	policyEngine *policy.Engine
+}
+
+// ServerOptions 服务器选项
+type ServerOptions struct {
+	// 本地存储文件路径，保存缓存快照和命名快照，为空时不启用
+	SnapshotFile string
+	// 快照保存间隔
+	SnapshotInterval time.Duration
+	// 离线转储来源（目录、tar/tar.gz归档或清单文件），设置后不连接集群
+	OfflineSource string
+	// 只读模式，禁用所有写操作接口
+	ReadOnly bool
+
+	// 审计日志文件路径（JSON Lines），为空时不写文件
+	AuditLogPath string
+	// 单个审计日志文件最大大小（MB），超出后轮转
+	AuditLogMaxSizeMB int
+	// 保留的轮转审计日志文件数
+	AuditLogMaxBackups int
+	// 审计事件webhook地址，为空时不发送
+	AuditWebhookURL string
+	// 审计策略文件，为空时所有请求使用AuditLevel
+	AuditPolicyFile string
+	// 默认审计级别：None、Metadata、Request、RequestResponse
+	AuditLevel string
+	// 可信认证代理的CIDR或IP，为空时不信任任何代理，审计用户记录为anonymous
+	TrustedProxies []string
+	// 可信代理传递用户名和用户组的请求头，按顺序取第一个非空值
+	AuditUserHeaders  []string
+	AuditGroupHeaders []string
+
+	// 标签分析中每个对象必须带有的标签，如team、cost-center
+	RequiredLabels []string
+
+	// 额外的Informer索引，格式为<resource>.<version>[.<group>]=<index>
+	Indexes []string
+
+	// 使用protobuf编码list/watch的内置资源，格式为<resource>.<version>[.<group>]，
+	// "all"表示所有内置资源，为空时全部使用JSON
+	ProtobufResources []string
+
+	// 使用WatchList（sendInitialEvents）流式完成Informer初始同步，服务器不支持时回退为分页LIST
+	WatchList bool
+	// 分页LIST每页的对象数，0表示使用client-go默认行为（从watch缓存LIST）；
+	// 大于0时从etcd分页读取，绕过watch缓存，只在需要限制单次响应大小时开启
+	ListPageSize int64
+
+	// Informer缓存的估算内存上限（字节），超出时驱逐最近最少使用的Informer，0表示不限制
+	MemoryBudget int64
+
+	// 响应缓存最多保存的结果数，0表示只合并并发请求不缓存
+	ResponseCacheSize int
+	// 响应缓存中结果的保留时间，对象变化时提前失效
+	ResponseCacheTTL time.Duration
+}
+
+// DefaultServerOptions 默认服务器选项
+func DefaultServerOptions() *ServerOptions {
+	return &ServerOptions{
+		SnapshotInterval:   5 * time.Minute,
+		ReadOnly:           true,
+		AuditLogMaxSizeMB:  100,
+		AuditLogMaxBackups: 5,
+		AuditLevel:         string(audit.LevelMetadata),
+		AuditUserHeaders:   DefaultUserHeaders,
+		AuditGroupHeaders:  DefaultGroupHeaders,
+		ProtobufResources:  []string{"pods.v1", "events.v1"},
+		ListPageSize:       0,
+		ResponseCacheSize:  256,
+		ResponseCacheTTL:   30 * time.Second,
+	}
 }
 
 // NewServer 创建新的API服务器
-func NewServer(config *rest.Config) (*Server, error) {
+func NewServer(config *rest.Config, options *ServerOptions) (*Server, error) {
+	if options == nil {
+		options = DefaultServerOptions()
+	}
+
+	server := &Server{
+		port:              "8080",
+		startTime:         time.Now(),
+		resourcesCacheTTL: 5 * time.Minute, // 资源列表缓存5分钟
+		responseCache:     newResponseCache(options.ResponseCacheSize, options.ResponseCacheTTL),
+		options:           options,
+	}
+
+	// 声明额外的Informer索引，必须在Informer启动前注册
+	for _, value := range options.Indexes {
+		gvr, spec, err := informer.ParseResourceIndex(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := informer.DefaultIndexRegistry().Register(gvr, spec); err != nil {
+			return nil, err
+		}
+	}
+
+	trustedProxies, err := parseTrustedProxies(options.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	server.trustedProxies = trustedProxies
+
+	// 初始化审计日志
+	auditLogger, err := newAuditLogger(options)
+	if err != nil {
+		return nil, err
+	}
+	server.auditLogger = auditLogger
+
+	// 打开本地存储（缓存快照和命名快照）
+	if options.SnapshotFile != "" {
+		snapshotStore, err := persistence.Open(options.SnapshotFile)
+		if err != nil {
+			server.closeAuditLogger()
+			return nil, err
+		}
+		server.snapshotStore = snapshotStore
+	}
+
+	// 初始化数据后端：离线转储或在线集群
+	if options.OfflineSource != "" {
+		err = server.initOfflineBackend(options.OfflineSource)
+	} else {
+		err = server.initLiveBackend(config)
+	}
+	if err != nil {
+		if server.snapshotStore != nil {
+			server.snapshotStore.Close()
+		}
+		server.closeAuditLogger()
+		return nil, err
+	}
+
+	// 使用快照中的资源列表，发现完成前即可响应
+	server.restoreResourcesSnapshot()
+
+	// 重新应用保存的策略
+	server.restorePolicies()
+
+	// 初始化路由
+	server.setupRoutes()
+
+	// 创建HTTP服务器，使用正确的router
+	server.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%s", server.port),
+		Handler: server.router,
+	}
+
+	// 异步预加载资源
+	go server.initializeCache()
+
+	return server, nil
+}
+
+// enableProtobuf 按ProtobufResources为内置资源启用protobuf编码的list/watch
+func (s *Server) enableProtobuf(informerManager *informer.InformerManager, config *rest.Config) error {
+	var resources []schema.GroupVersionResource
+	all := false
+	for _, value := range s.options.ProtobufResources {
+		if value == "all" {
+			all = true
+			continue
+		}
+		gvr, err := informer.ParseResource(value)
+		if err != nil {
+			return fmt.Errorf("invalid protobuf resource: %v", err)
+		}
+		resources = append(resources, gvr)
+	}
+	if !all && len(resources) == 0 {
+		return nil
+	}
+	return informerManager.EnableProtobuf(config, resources, all)
+}
+
+// initLiveBackend 创建Kubernetes客户端和基于Informer的缓存
+func (s *Server) initLiveBackend(config *rest.Config) error {
 	// 优化客户端配置
 	config.QPS = 100   // 增加QPS限制
 	config.Burst = 200 // 增加突发限制
@@ -59,48 +262,68 @@ func NewServer(config *rest.Config) (*Server, error) {
 	// 创建客户端
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create clientset: %v", err)
+		return fmt.Errorf("failed to create clientset: %v", err)
+	}
+
+	// http.Client.Timeout同样限制读取响应体的时间，流式请求使用不设超时的客户端
+	streamConfig := rest.CopyConfig(config)
+	streamConfig.Timeout = 0
+	streamClientset, err := kubernetes.NewForConfig(streamConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create streaming clientset: %v", err)
 	}
 
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create dynamic client: %v", err)
+		return fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create discovery client: %v", err)
+		return fmt.Errorf("failed to create discovery client: %v", err)
 	}
 
 	// 创建Informer管理器
 	informerManager := informer.NewInformerManager(dynamicClient)
-	strategy := informer.DefaultStrategy()
-	strategyManager := informer.NewStrategyManager(informerManager, strategy)
 
-	server := &Server{
-		clientset:           clientset,
-		dynamicClient:       dynamicClient,
-		discoveryClient:     discoveryClient,
-		strategyManager:     strategyManager,
-		port:                "8080",
-		startTime:           time.Now(),
-		resourcesCacheTTL:   5 * time.Minute, // 资源列表缓存5分钟
-		requestDeduplicator: make(map[string]*sync.Mutex),
+	// Informer启动时从快照恢复
+	if s.snapshotStore != nil {
+		informerManager.SetSnapshotStore(s.snapshotStore)
 	}
 
-	// 初始化路由
-	server.setupRoutes()
+	// 初始同步方式：WatchList或分页LIST
+	informerManager.SetSyncOptions(informer.SyncOptions{
+		WatchList: s.options.WatchList,
+		PageSize:  s.options.ListPageSize,
+	})
 
-	// 创建HTTP服务器，使用正确的router
-	server.httpServer = &http.Server{
-		Addr:    fmt.Sprintf(":%s", server.port),
-		Handler: server.router,
+	// 大型内置资源使用protobuf减少初始同步的解码开销
+	if err := s.enableProtobuf(informerManager, config); err != nil {
+		return err
 	}
 
-	// 异步预加载资源
-	go server.initializeCache()
+	s.clientset = clientset
+	s.streamClientset = streamClientset
+	s.dynamicClient = dynamicClient
+	s.discoveryClient = discoveryClient
+	s.informerManager = informerManager
+	strategy := informer.DefaultStrategy()
+	strategy.MemoryBudgetBytes = s.options.MemoryBudget
+	s.strategyManager = informer.NewStrategyManager(informerManager, strategy)
 
-	return server, nil
+	s.initPolicyEngine(informerManager)
+
+	// 对象变化时丢弃该资源的缓存响应
+	informerManager.AddEventListener(s.responseCache.OnEvent)
+	informerManager.AddStopListener(s.responseCache.OnStop)
+
+	// 迁移进度保存在本地存储中，未配置存储时只保存在内存
+	if s.snapshotStore != nil {
+		s.migrator = migration.NewMigrator(dynamicClient, s.snapshotStore)
+	} else {
+		s.migrator = migration.NewMigrator(dynamicClient, nil)
+	}
+	return nil
 }
 
 // Router 返回 gin 路由器
@@ -111,12 +334,23 @@ func (s *Server) Router() *gin.Engine {
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
 	s.router = gin.Default()
+	// ClientIP只在请求来自可信代理时使用X-Forwarded-For，地址已在NewServer中校验
+	if err := s.router.SetTrustedProxies(s.options.TrustedProxies); err != nil {
+		klog.Warningf("Failed to set trusted proxies: %v", err)
+	}
 
-	// 启用CORS
+	// 启用CORS，只允许跨源读取；跨源页面发起的写请求直接拒绝，
+	// 避免关闭只读模式后任意网页借用户的浏览器修改集群对象
 	s.router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if isSafeMethod(c.Request.Method) {
+			c.Header("Access-Control-Allow-Origin", "*")
+			c.Header("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-None-Match")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Cache-Stale, X-Discovery-Failures, ETag")
+		} else if !sameOrigin(c.Request) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "cross-origin write requests are not allowed"})
+			return
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -126,8 +360,10 @@ func (s *Server) setupRoutes() {
 		c.Next()
 	})
 
-	// 添加性能监控中间件
+	// 请求ID、性能监控和审计中间件
+	s.router.Use(requestIDMiddleware())
 	s.router.Use(s.performanceMiddleware())
+	s.router.Use(s.auditMiddleware())
 
 	// API路由
 	api := s.router.Group("/api")
@@ -135,11 +371,59 @@ func (s *Server) setupRoutes() {
 		api.GET("/crds", s.getCRDs)
 		api.GET("/crds/:group/:version/:resource/objects", s.getResourceObjects)
 		api.GET("/crds/:group/:version/:resource/objects/fast", s.getResourceObjectsFast) // 新增快速接口
+		api.GET("/crds/:group/:version/:resource/objects/export", s.exportResourceObjects)
 		api.GET("/crds/:group/:version/:resource/namespaces", s.getResourceNamespaces)
+		api.GET("/crds/:group/:version/:resource/objects/:name/status", s.getResourceObjectStatus)
+		api.GET("/crds/:group/:version/:resource/objects/:name/scale", s.getResourceObjectScale)
+
+		// 写操作（默认只读，需通过 -read-only=false 开启）
+		writes := api.Group("/crds/:group/:version/:resource/objects", s.writeGuard())
+		{
+			writes.PUT("/:name", s.updateResourceObject)
+			writes.PATCH("/:name", s.patchResourceObject)
+			writes.DELETE("/:name", s.deleteResourceObject)
+			writes.POST("/:name/scale", s.scaleResourceObject)
+			writes.POST("/:name/restart", s.restartResourceObject)
+			writes.POST("/:name/rollback", s.rollbackDeployment)
+		}
+
 		api.GET("/namespaces", s.getNamespaces)
+		api.GET("/pods/:namespace/:name/logs", s.getPodLogs)
 		api.GET("/cache/stats", s.getCacheStats)
 		api.GET("/cache/status", s.getCacheStatus)           // 新增缓存状态接口
 		api.GET("/performance/stats", s.getPerformanceStats) // 新增性能统计接口
+		api.GET("/cache/indexes", s.getCacheIndexes)
+		api.GET("/diagnostics", s.getDiagnostics)
+		api.GET("/deprecations/report", s.getDeprecationReport)
+
+		// 标签和注解分析
+		api.GET("/analytics/labels", s.getLabelAnalytics)
+
+		// 资源请求、限制和配额汇总
+		api.GET("/capacity", s.getCapacityReport)
+		api.GET("/nodes", s.getNodes)
+		api.GET("/nodes/:name", s.getNode)
+
+		// 策略检查
+		api.GET("/policies", s.listPolicies)
+		api.PUT("/policies/:name", s.putPolicy)
+		api.DELETE("/policies/:name", s.deletePolicy)
+		api.GET("/policies/:name/report", s.getPolicyReport)
+		api.GET("/tools/crds/:name/conversion", s.inspectCRDConversion)
+		api.POST("/tools/crds/:name/conversion", s.writeGuard(), s.roundTripCRDConversion)
+
+		// 存储版本迁移，开始和取消受只读开关约束
+		api.GET("/migrations", s.listMigrations)
+		api.GET("/migrations/:name", s.getMigration)
+		api.POST("/migrations/:name", s.writeGuard(), s.startMigration)
+		api.DELETE("/migrations/:name", s.writeGuard(), s.cancelMigration)
+
+		// 命名快照与差异比较
+		api.GET("/snapshots", s.listSnapshots)
+		api.POST("/snapshots", s.createSnapshot)
+		api.GET("/snapshots/:name", s.getSnapshot)
+		api.DELETE("/snapshots/:name", s.deleteSnapshot)
+		api.GET("/snapshots/:name/diff", s.diffSnapshot)
 	}
 
 	// 健康检查端点
@@ -393,8 +677,10 @@ func (s *Server) performanceMiddleware() gin.HandlerFunc {
 			klog.V(2).Infof("Moderate request: %s %s took %v", param.Method, param.Path, param.Latency)
 		}
 
-		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n",
+		requestID, _ := param.Keys[requestIDKey].(string)
+		return fmt.Sprintf("[GIN] %v | %s | %3d | %13v | %15s | %-7s %#v\n",
 			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			requestID,
 			param.StatusCode,
 			param.Latency,
 			param.ClientIP,
@@ -412,8 +698,18 @@ func (s *Server) initializeCache() {
 	// 获取所有资源
 	resources, err := s.getAllResources()
 	if err != nil {
-		klog.Errorf("Failed to get resources for cache initialization: %v", err)
-		return
+		// API服务器不可用时使用快照中的资源列表继续启动
+		s.resourcesCacheMutex.RLock()
+		resources = s.resourcesCache
+		s.resourcesCacheMutex.RUnlock()
+
+		if len(resources) == 0 {
+			klog.Errorf("Failed to get resources for cache initialization: %v", err)
+			return
+		}
+		klog.Warningf("Failed to get resources, continuing with %d resources from snapshot: %v", len(resources), err)
+	} else {
+		s.saveResourcesSnapshot(resources)
 	}
 
 	klog.Infof("Found %d resources, starting preload...", len(resources))
@@ -485,8 +781,46 @@ func (s *Server) initializeCache() {
 	// 启动后台监控
 	go s.startBackgroundMonitoring()
 
-	// 启动定期清理
-	go s.startPeriodicCleanup()
+	// 启动定期快照
+	if s.snapshotStore != nil && s.informerManager != nil {
+		go s.informerManager.StartSnapshotLoop(s.options.SnapshotInterval)
+	}
+}
+
+// restoreResourcesSnapshot 从快照恢复资源列表缓存
+func (s *Server) restoreResourcesSnapshot() {
+	// 离线模式的资源列表来自转储本身
+	if s.snapshotStore == nil || s.offlineCache != nil {
+		return
+	}
+
+	var resources []Resource
+	found, err := s.snapshotStore.LoadValue("resources", &resources)
+	if err != nil {
+		klog.Warningf("Failed to load resources from snapshot: %v", err)
+		return
+	}
+	if !found || len(resources) == 0 {
+		return
+	}
+
+	s.resourcesCacheMutex.Lock()
+	s.resourcesCache = resources
+	s.resourcesCacheTime = time.Now()
+	s.resourcesCacheMutex.Unlock()
+
+	klog.Infof("Restored %d resources from snapshot", len(resources))
+}
+
+// saveResourcesSnapshot 保存资源列表到快照
+func (s *Server) saveResourcesSnapshot(resources []Resource) {
+	if s.snapshotStore == nil || s.offlineCache != nil {
+		return
+	}
+
+	if err := s.snapshotStore.SaveValue("resources", resources); err != nil {
+		klog.Warningf("Failed to save resources to snapshot: %v", err)
+	}
 }
 
 // startBackgroundMonitoring 启动后台监控
@@ -506,32 +840,6 @@ func (s *Server) startBackgroundMonitoring() {
 	}
 }
 
-// startPeriodicCleanup 启动定期清理
-func (s *Server) startPeriodicCleanup() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			s.cleanupRequestDeduplicator()
-		}
-	}
-}
-
-// cleanupRequestDeduplicator 清理请求去重器
-func (s *Server) cleanupRequestDeduplicator() {
-	s.deduplicatorMutex.Lock()
-	defer s.deduplicatorMutex.Unlock()
-
-	// 清理所有互斥锁，因为它们只是用于短期去重
-	// 长期缓存由其他机制处理
-	if len(s.requestDeduplicator) > 100 {
-		klog.V(4).Infof("Cleaning up request deduplicator, current size: %d", len(s.requestDeduplicator))
-		s.requestDeduplicator = make(map[string]*sync.Mutex)
-	}
-}
-
 // SetReady 设置服务就绪状态
 func (s *Server) SetReady(ready bool) {
 	s.isReady.Store(ready)
@@ -550,66 +858,43 @@ func (s *Server) getCRDs(c *gin.Context) {
 		resources := s.resourcesCache
 		s.resourcesCacheMutex.RUnlock()
 		klog.V(4).Infof("Returning cached resources: %d", len(resources))
-		c.JSON(http.StatusOK, resources)
+		s.writeResources(c, resources)
 		return
 	}
 	s.resourcesCacheMutex.RUnlock()
 
-	// 请求去重
-	requestKey := "getAllResources"
-	mutex := s.getOrCreateRequestMutex(requestKey)
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// 再次检查缓存（可能在等待锁的过程中已被更新）
-	s.resourcesCacheMutex.RLock()
-	if time.Since(s.resourcesCacheTime) < s.resourcesCacheTTL && len(s.resourcesCache) > 0 {
-		resources := s.resourcesCache
+	// 合并并发的资源发现请求
+	value, err := s.responseCache.Coalesce(c.Request.Context(), "resources", func(ctx context.Context) (interface{}, error) {
+		// 再次检查缓存（可能在前一轮合并的请求中已被更新）
+		s.resourcesCacheMutex.RLock()
+		if time.Since(s.resourcesCacheTime) < s.resourcesCacheTTL && len(s.resourcesCache) > 0 {
+			resources := s.resourcesCache
+			s.resourcesCacheMutex.RUnlock()
+			return resources, nil
+		}
 		s.resourcesCacheMutex.RUnlock()
-		klog.V(4).Infof("Returning cached resources after lock: %d", len(resources))
-		c.JSON(http.StatusOK, resources)
-		return
-	}
-	s.resourcesCacheMutex.RUnlock()
 
-	// 获取资源
-	resources, err := s.getAllResources()
+		resources, err := s.getAllResources()
+		if err != nil {
+			return nil, err
+		}
+
+		// 更新缓存
+		s.resourcesCacheMutex.Lock()
+		s.resourcesCache = resources
+		s.resourcesCacheTime = time.Now()
+		s.resourcesCacheMutex.Unlock()
+		return resources, nil
+	})
 	if err != nil {
 		klog.Errorf("Failed to get CRDs: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 更新缓存
-	s.resourcesCacheMutex.Lock()
-	s.resourcesCache = resources
-	s.resourcesCacheTime = time.Now()
-	s.resourcesCacheMutex.Unlock()
+	resources := value.([]Resource)
 
 	klog.V(2).Infof("Found %d resources, cached for %v", len(resources), s.resourcesCacheTTL)
-	c.JSON(http.StatusOK, resources)
-}
-
-// getOrCreateRequestMutex 获取或创建请求互斥锁
-func (s *Server) getOrCreateRequestMutex(key string) *sync.Mutex {
-	s.deduplicatorMutex.RLock()
-	if mutex, exists := s.requestDeduplicator[key]; exists {
-		s.deduplicatorMutex.RUnlock()
-		return mutex
-	}
-	s.deduplicatorMutex.RUnlock()
-
-	s.deduplicatorMutex.Lock()
-	defer s.deduplicatorMutex.Unlock()
-
-	// 双重检查
-	if mutex, exists := s.requestDeduplicator[key]; exists {
-		return mutex
-	}
-
-	mutex := &sync.Mutex{}
-	s.requestDeduplicator[key] = mutex
-	return mutex
+	s.writeResources(c, resources)
 }
 
 // getResourceObjects 获取资源对象（使用Informer缓存，优化版本）
@@ -631,38 +916,72 @@ func (s *Server) getResourceObjects(c *gin.Context) {
 		Resource: resource,
 	}
 
-	// 请求去重
-	requestKey := fmt.Sprintf("objects_%s_%s_%s_%s", group, version, resource, namespace)
-	mutex := s.getOrCreateRequestMutex(requestKey)
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	klog.V(4).Infof("Getting objects for resource: %s/%s/%s, namespace: %s", group, version, resource, namespace)
-
-	// 检查资源是否为命名空间资源（带缓存）
-	namespaced, err := s.isNamespacedResourceCached(gvr)
+	selector, err := selectorFromQuery(c)
 	if err != nil {
-		klog.Errorf("Failed to check if resource is namespaced: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 使用策略管理器获取对象
-	objects, err := s.strategyManager.GetObjects(gvr, namespace, namespaced)
+	// 相同请求合并执行，来自Informer缓存的结果缓存到对象变化为止
+	requestKey := fmt.Sprintf("objects|%s|%s|%s|%s|%s", gvr.String(), namespace,
+		c.Query("labelSelector"), c.Query("fieldSelector"), c.Query("ownerUID"))
+	value, etag, err := s.responseCache.Do(c.Request.Context(), requestKey, gvr.String(), func(ctx context.Context) (interface{}, bool, error) {
+		klog.V(4).Infof("Getting objects for resource: %s/%s/%s, namespace: %s", group, version, resource, namespace)
+
+		// 检查资源是否为命名空间资源（带缓存）
+		namespaced, err := s.isNamespacedResourceCached(gvr)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// 使用策略管理器获取对象，选择器命中索引时不扫描整个缓存
+		objects, source, err := s.strategyManager.SelectObjects(ctx, gvr, namespace, namespaced, selector)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// 优化：预分配切片容量
+		result := make([]map[string]interface{}, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.Object)
+		}
+		klog.V(4).Infof("Retrieved %d objects for %s from %s", len(result), gvr.String(), source)
+
+		// 快照中尚未重新同步的数据在重新LIST后可能没有对象事件，不缓存
+		stale := source == informer.SourceCache && s.strategyManager.IsStale(gvr)
+		return objectsResponse{
+			objects: result,
+			source:  source,
+			stale:   stale,
+		}, source == informer.SourceCache && !stale, nil
+	})
 	if err != nil {
-		klog.Errorf("Failed to get objects from cache: %v", err)
+		klog.Errorf("Failed to get objects: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	response := value.(objectsResponse)
+	if response.source == informer.SourceCache {
+		s.strategyManager.RecordAccess(gvr)
+	}
 
-	// 优化：预分配切片容量
-	result := make([]map[string]interface{}, 0, len(objects))
-	for _, obj := range objects {
-		result = append(result, obj.Object)
+	// 标记数据来源（Informer缓存或直接LIST）以及快照中尚未重新同步的数据
+	c.Header("X-Data-Source", string(response.source))
+	if response.stale {
+		c.Header("X-Cache-Stale", "true")
 	}
+	if notModified(c, etag) {
+		return
+	}
+
+	writeJSONList(c, response.objects, "", nil)
+}
 
-	klog.V(4).Infof("Retrieved %d objects for %s from cache", len(result), gvr.String())
-	c.JSON(http.StatusOK, result)
+// objectsResponse 对象列表请求的结果，可在响应缓存中共享
+type objectsResponse struct {
+	objects []map[string]interface{}
+	source  informer.Source
+	stale   bool
 }
 
 // getResourceObjectsFast 快速获取资源对象（带降级策略）
@@ -692,8 +1011,8 @@ func (s *Server) getResourceObjectsFast(c *gin.Context) {
 		return
 	}
 
-	// 使用降级策略获取对象
-	objects, err := s.strategyManager.GetObjectsWithFallback(gvr, namespace, namespaced)
+	// Informer未就绪时直接LIST，不返回空结果
+	objects, source, err := s.strategyManager.GetObjectsWithSource(c.Request.Context(), gvr, namespace, namespaced)
 	if err != nil {
 		klog.Errorf("Failed to get objects with fallback: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -707,13 +1026,12 @@ func (s *Server) getResourceObjectsFast(c *gin.Context) {
 	}
 
 	// 添加加载状态信息
-	response := gin.H{
-		"objects": result,
-		"loading": !s.strategyManager.GetCacheStats().SyncStatus[gvr.String()],
+	writeJSONList(c, result, "objects", gin.H{
+		"loading": !s.strategyManager.IsReady(gvr),
+		"stale":   source == informer.SourceCache && s.strategyManager.IsStale(gvr),
+		"source":  source,
 		"count":   len(result),
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
 // getResourceNamespaces 获取资源的命名空间（使用Informer缓存，优化版本）
@@ -734,38 +1052,45 @@ func (s *Server) getResourceNamespaces(c *gin.Context) {
 		Resource: resource,
 	}
 
-	// 请求去重
-	requestKey := fmt.Sprintf("namespaces_%s_%s_%s", group, version, resource)
-	mutex := s.getOrCreateRequestMutex(requestKey)
-	mutex.Lock()
-	defer mutex.Unlock()
+	// 相同请求合并执行，Informer已就绪时结果缓存到对象变化为止
+	requestKey := "namespaces|" + gvr.String()
+	value, etag, err := s.responseCache.Do(c.Request.Context(), requestKey, gvr.String(), func(ctx context.Context) (interface{}, bool, error) {
+		klog.V(4).Infof("Getting namespaces for resource: %s/%s/%s", group, version, resource)
 
-	klog.V(4).Infof("Getting namespaces for resource: %s/%s/%s", group, version, resource)
+		// 检查资源是否为命名空间资源（带缓存）
+		namespaced, err := s.isNamespacedResourceCached(gvr)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !namespaced {
+			// 非命名空间资源返回空数组
+			return []string{}, false, nil
+		}
 
-	// 检查资源是否为命名空间资源（带缓存）
-	namespaced, err := s.isNamespacedResourceCached(gvr)
+		// 未就绪时GetNamespaces直接LIST，这类结果不缓存
+		ready := s.strategyManager.IsReady(gvr)
+		namespaces, err := s.strategyManager.GetNamespaces(ctx, gvr, namespaced)
+		if err != nil {
+			return nil, false, err
+		}
+
+		klog.V(4).Infof("Retrieved %d namespaces for %s", len(namespaces), gvr.String())
+		return namespaces, ready, nil
+	})
 	if err != nil {
-		klog.Errorf("Failed to check if resource is namespaced: %v", err)
+		klog.Errorf("Failed to get namespaces: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	if !namespaced {
-		// 非命名空间资源返回空数组
-		c.JSON(http.StatusOK, []string{})
-		return
+	if etag != "" {
+		s.strategyManager.RecordAccess(gvr)
 	}
-
-	// 使用策略管理器获取命名空间
-	namespaces, err := s.strategyManager.GetNamespaces(gvr, namespaced)
-	if err != nil {
-		klog.Errorf("Failed to get namespaces from cache: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if notModified(c, etag) {
 		return
 	}
 
-	klog.V(4).Infof("Retrieved %d namespaces for %s from cache", len(namespaces), gvr.String())
-	c.JSON(http.StatusOK, namespaces)
+	writeJSONList(c, value.([]string), "", nil)
 }
 
 // getCacheStats 获取缓存统计信息
@@ -774,6 +1099,11 @@ func (s *Server) getCacheStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// getCacheIndexes 列出各资源声明的Informer索引，可用于labelSelector、fieldSelector和ownerUID查询
+func (s *Server) getCacheIndexes(c *gin.Context) {
+	c.JSON(http.StatusOK, informer.DefaultIndexRegistry().All())
+}
+
 // getCacheStatus 获取缓存状态
 func (s *Server) getCacheStatus(c *gin.Context) {
 	stats := s.strategyManager.GetCacheStats()
@@ -786,6 +1116,9 @@ func (s *Server) getCacheStatus(c *gin.Context) {
 		"totalObjects":    stats.TotalObjects,
 		"uptime":          time.Since(s.startTime).String(),
 	}
+	if s.offlineCache != nil {
+		status["offlineSource"] = s.offlineCache.Source()
+	}
 
 	c.JSON(http.StatusOK, status)
 }
@@ -809,11 +1142,13 @@ func (s *Server) getPerformanceStats(c *gin.Context) {
 		avgSyncTime = totalSyncTime / time.Duration(syncCount)
 	}
 
+	responseStats := s.responseCache.Stats()
 	performance := gin.H{
 		"uptime":          time.Since(s.startTime).String(),
 		"averageSyncTime": avgSyncTime.String(),
 		"totalSyncCount":  syncCount,
-		"cacheHitRate":    "N/A", // 可以后续添加缓存命中率统计
+		"cacheHitRate":    responseStats.HitRate,
+		"responseCache":   responseStats,
 		"memoryUsage":     "N/A", // 可以后续添加内存使用统计
 	}
 
@@ -822,6 +1157,11 @@ func (s *Server) getPerformanceStats(c *gin.Context) {
 
 // getNamespaces 获取所有命名空间
 func (s *Server) getNamespaces(c *gin.Context) {
+	if s.offlineCache != nil {
+		writeJSONList(c, s.offlineCache.AllNamespaces(), "", nil)
+		return
+	}
+
 	namespaces, err := s.clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		klog.Errorf("Failed to get namespaces: %v", err)
@@ -835,23 +1175,33 @@ func (s *Server) getNamespaces(c *gin.Context) {
 	}
 
 	sort.Strings(result)
-	c.JSON(http.StatusOK, result)
+	writeJSONList(c, result, "", nil)
 }
 
 // getAllResources 获取所有资源（保持原有逻辑）
 func (s *Server) getAllResources() ([]Resource, error) {
+	if s.offlineCache != nil {
+		return s.offlineResources(), nil
+	}
+
 	// 获取API资源列表
 	_, apiResourceLists, err := s.discoveryClient.ServerGroupsAndResources()
 	if err != nil {
 		// 处理部分错误，继续获取可用资源
 		if discovery.IsGroupDiscoveryFailedError(err) {
 			klog.Warningf("Some groups were not discoverable: %v", err)
+			s.setDiscoveryFailures(err.(*discovery.ErrGroupDiscoveryFailed))
 		} else {
 			return nil, fmt.Errorf("failed to get server groups and resources: %v", err)
 		}
 	}
 
+	if err == nil {
+		s.setDiscoveryFailures(nil)
+	}
+
 	var resources []Resource
+	served := deprecation.ServedResources(apiResourceLists)
 
 	for _, apiResourceList := range apiResourceLists {
 		if apiResourceList == nil {
@@ -864,6 +1214,14 @@ func (s *Server) getAllResources() ([]Resource, error) {
 			continue
 		}
 
+		// 收集子资源（如deployments/scale），挂到所属资源上
+		subresources := make(map[string][]string)
+		for _, apiResource := range apiResourceList.APIResources {
+			if parent, sub, ok := strings.Cut(apiResource.Name, "/"); ok {
+				subresources[parent] = append(subresources[parent], sub)
+			}
+		}
+
 		for _, apiResource := range apiResourceList.APIResources {
 			// 跳过子资源
 			if strings.Contains(apiResource.Name, "/") {
@@ -875,14 +1233,14 @@ func (s *Server) getAllResources() ([]Resource, error) {
 				continue
 			}
 
-			// 跳过已弃用的资源版本
-			if isDeprecatedResource(apiResource.Name, gv.Group, gv.Version) {
+			// 跳过已有替代版本的弃用资源版本，集群只提供弃用版本时仍然展示
+			if deprecation.Default().IsSuperseded(gv.Group, gv.Version, apiResource.Name, served) {
 				klog.V(4).Infof("Skipping deprecated resource: %s/%s %s", gv.Group, gv.Version, apiResource.Name)
 				continue
 			}
 
 			// 跳过特殊资源
-			if isSpecialResource(apiResource.Name, gv.Group) {
+			if deprecation.Default().IsExcluded(gv.Group, apiResource.Name) {
 				continue
 			}
 
@@ -893,6 +1251,10 @@ func (s *Server) getAllResources() ([]Resource, error) {
 				Kind:       apiResource.Kind,
 				Namespaced: apiResource.Namespaced,
 			}
+			if subs := subresources[apiResource.Name]; len(subs) > 0 {
+				sort.Strings(subs)
+				resource.Subresources = subs
+			}
 
 			resources = append(resources, resource)
 		}
@@ -920,92 +1282,31 @@ func hasVerb(r metav1.APIResource, verb string) bool {
 	return false
 }
 
-// isDeprecatedResource 检查是否为已弃用的资源版本
-func isDeprecatedResource(name, group, version string) bool {
-	// 已弃用的资源版本映射
-	deprecatedResources := map[string]map[string][]string{
-		"batch": {
-			"v1beta1": {"cronjobs"}, // batch/v1beta1 CronJob 在 v1.21+ 中已弃用，在 v1.25+ 中不可用
-		},
-		"extensions": {
-			"v1beta1": {"deployments", "replicasets", "daemonsets", "ingresses", "podsecuritypolicies"},
-		},
-		"apps": {
-			"v1beta1": {"deployments", "replicasets", "daemonsets", "statefulsets"},
-			"v1beta2": {"deployments", "replicasets", "daemonsets", "statefulsets"},
-		},
-		"networking.k8s.io": {
-			"v1beta1": {"ingresses"}, // 使用 v1 版本
-		},
-		"policy": {
-			"v1beta1": {"podsecuritypolicies"}, // PodSecurityPolicy 已弃用
-		},
-		"apiregistration.k8s.io": {
-			"v1beta1": {"apiservices"}, // 使用 v1 版本
-		},
-		"admissionregistration.k8s.io": {
-			"v1beta1": {"mutatingwebhookconfigurations", "validatingwebhookconfigurations"}, // 使用 v1 版本
-		},
-		"scheduling.k8s.io": {
-			"v1beta1": {"priorityclasses"}, // 使用 v1 版本
-		},
-		"storage.k8s.io": {
-			"v1beta1": {"storageclasses", "volumeattachments"}, // 使用 v1 版本
-		},
-		"rbac.authorization.k8s.io": {
-			"v1beta1": {"roles", "rolebindings", "clusterroles", "clusterrolebindings"}, // 使用 v1 版本
-		},
-	}
-
-	if groupMap, ok := deprecatedResources[group]; ok {
-		if versionList, ok := groupMap[version]; ok {
-			for _, r := range versionList {
-				if r == name {
-					return true
-				}
-			}
-		}
-	}
-	return false
+// selectorFromQuery 从labelSelector、fieldSelector和ownerUID查询参数构建选择器
+func selectorFromQuery(c *gin.Context) (informer.Selector, error) {
+	return informer.ParseSelector(c.Query("labelSelector"), c.Query("fieldSelector"), c.Query("ownerUID"))
 }
 
-// isSpecialResource 检查是否为特殊资源（需要排除的资源）
-func isSpecialResource(name, group string) bool {
-	specialResources := map[string][]string{
-		"": {
-			"componentstatuses", // 已弃用的ComponentStatus
-			"bindings",          // 特殊绑定资源
-		},
-		"authorization.k8s.io": {
-			"selfsubjectrulesreviews",
-			"subjectaccessreviews",
-			"localsubjectaccessreviews",
-			"selfsubjectaccessreviews",
-		},
-		"authentication.k8s.io": {
-			"tokenreviews",
-		},
-		"metrics.k8s.io": {
-			"pods",
-			"nodes",
-		},
-		"events.k8s.io": {
-			"events", // 避免重复，使用 core/v1 events
-		},
-	}
-
-	if resources, ok := specialResources[group]; ok {
-		for _, r := range resources {
-			if r == name {
-				return true
-			}
-		}
+// gvrFromParams 从路由参数构建GVR（core组表示核心组）
+func gvrFromParams(c *gin.Context) schema.GroupVersionResource {
+	group := c.Param("group")
+	if group == "core" {
+		group = ""
+	}
+
+	return schema.GroupVersionResource{
+		Group:    group,
+		Version:  c.Param("version"),
+		Resource: c.Param("resource"),
 	}
-	return false
 }
 
 // isNamespacedResource 检查资源是否为命名空间资源
 func (s *Server) isNamespacedResource(gvr schema.GroupVersionResource) (bool, error) {
+	if s.offlineCache != nil {
+		return s.isNamespacedOfflineResource(gvr)
+	}
+
 	// 从discovery客户端获取资源信息
 	_, apiResourceLists, err := s.discoveryClient.ServerGroupsAndResources()
 	if err != nil {
@@ -1055,6 +1356,8 @@ type Resource struct {
 	Name       string `json:"name"`
 	Kind       string `json:"kind"`
 	Namespaced bool   `json:"namespaced"`
+	// Subresources 资源声明的子资源，如status、scale
+	Subresources []string `json:"subresources,omitempty"`
 }
 
 // Run 启动服务器
@@ -1073,14 +1376,38 @@ func (s *Server) Start() error {
 // Shutdown 关闭服务器
 func (s *Server) Shutdown() {
 	klog.Info("Shutting down server")
-	s.strategyManager.Shutdown()
 
+	// 先停止接收新请求并等待处理中的请求完成，之后不再有请求访问Informer和本地存储
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		klog.Errorf("Server shutdown error: %v", err)
 	}
+
+	s.strategyManager.Shutdown()
+
+	// 先停止迁移，保存进度后再关闭存储
+	if s.migrator != nil {
+		s.migrator.Shutdown()
+	}
+
+	// Informer关闭时已写入最新快照
+	if s.snapshotStore != nil {
+		if err := s.snapshotStore.Close(); err != nil {
+			klog.Errorf("Failed to close snapshot store: %v", err)
+		}
+	}
+
+	// 请求处理完成后再关闭审计日志，确保最后的事件被写入
+	s.closeAuditLogger()
+}
+
+// closeAuditLogger 关闭审计日志输出
+func (s *Server) closeAuditLogger() {
+	if s.auditLogger != nil {
+		s.auditLogger.Close()
+	}
 }
 
 // healthCheck 健康检查端点
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/jicki/crds-objects-browser/pkg/diff"
+	"github.com/jicki/crds-objects-browser/pkg/persistence"
+)
+
+// liveSnapshotName 表示与当前缓存比较
+const liveSnapshotName = "live"
+
+// createSnapshotRequest 创建命名快照的请求
+type createSnapshotRequest struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Resources []struct {
+		Group    string `json:"group"`
+		Version  string `json:"version"`
+		Resource string `json:"resource"`
+	} `json:"resources"`
+}
+
+// requireSnapshotStore 检查快照存储是否已配置，返回false表示已写入响应
+func (s *Server) requireSnapshotStore(c *gin.Context) bool {
+	if s.snapshotStore != nil {
+		return true
+	}
+
+	c.JSON(http.StatusNotImplemented, gin.H{"error": "snapshot storage is not configured, start the server with -snapshot-file"})
+	return false
+}
+
+// createSnapshot 从Informer缓存中捕获选定资源的命名快照
+func (s *Server) createSnapshot(c *gin.Context) {
+	if !s.requireSnapshotStore(c) {
+		return
+	}
+
+	var req createSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.Name == "" || req.Name == liveSnapshotName || strings.Contains(req.Name, "/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid snapshot name %q", req.Name)})
+		return
+	}
+	if len(req.Resources) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one resource is required"})
+		return
+	}
+
+	snapshot := persistence.NamedSnapshot{
+		Name:      req.Name,
+		CreatedAt: time.Now(),
+		Namespace: req.Namespace,
+	}
+	lists := make(map[schema.GroupVersionResource]*unstructured.UnstructuredList, len(req.Resources))
+
+	for _, res := range req.Resources {
+		gvr := schema.GroupVersionResource{Group: res.Group, Version: res.Version, Resource: res.Resource}
+		if gvr.Group == "core" {
+			gvr.Group = ""
+		}
+		// 重复的资源只捕获一次，每个资源在快照中对应一个存储桶
+		if _, ok := lists[gvr]; ok {
+			continue
+		}
+
+		objects, err := s.getCachedObjects(c.Request.Context(), gvr, req.Namespace)
+		if err != nil {
+			klog.Errorf("Failed to capture %s for snapshot %s: %v", gvr.String(), req.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, len(objects))}
+		for _, obj := range objects {
+			list.Items = append(list.Items, *obj)
+		}
+		lists[gvr] = list
+
+		snapshot.Resources = append(snapshot.Resources, persistence.SnapshotResource{
+			Group:       gvr.Group,
+			Version:     gvr.Version,
+			Resource:    gvr.Resource,
+			ObjectCount: len(objects),
+		})
+		snapshot.ObjectCount += len(objects)
+	}
+
+	if err := s.snapshotStore.SaveNamedSnapshot(snapshot, lists); err != nil {
+		klog.Errorf("Failed to save snapshot %s: %v", req.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	klog.Infof("Captured snapshot %s with %d objects of %d resources", snapshot.Name, snapshot.ObjectCount, len(snapshot.Resources))
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// listSnapshots 列出所有命名快照
+func (s *Server) listSnapshots(c *gin.Context) {
+	if !s.requireSnapshotStore(c) {
+		return
+	}
+
+	snapshots, err := s.snapshotStore.ListNamedSnapshots()
+	if err != nil {
+		klog.Errorf("Failed to list snapshots: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+	writeJSONList(c, snapshots, "", nil)
+}
+
+// getSnapshot 获取命名快照的元数据
+func (s *Server) getSnapshot(c *gin.Context) {
+	if !s.requireSnapshotStore(c) {
+		return
+	}
+
+	snapshot, _, err := s.snapshotStore.LoadNamedSnapshot(c.Param("name"))
+	if err != nil {
+		writeSnapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// deleteSnapshot 删除命名快照
+func (s *Server) deleteSnapshot(c *gin.Context) {
+	if !s.requireSnapshotStore(c) {
+		return
+	}
+
+	if err := s.snapshotStore.DeleteNamedSnapshot(c.Param("name")); err != nil {
+		writeSnapshotError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// diffSnapshot 比较两个命名快照，或命名快照与当前缓存
+// 查询参数：against=<快照名>|live（默认live），ignore=逗号分隔的额外忽略字段
+func (s *Server) diffSnapshot(c *gin.Context) {
+	if !s.requireSnapshotStore(c) {
+		return
+	}
+
+	name := c.Param("name")
+	against := c.DefaultQuery("against", liveSnapshotName)
+
+	snapshot, fromLists, err := s.snapshotStore.LoadNamedSnapshot(name)
+	if err != nil {
+		writeSnapshotError(c, err)
+		return
+	}
+	from := listsToObjects(fromLists)
+
+	var to map[schema.GroupVersionResource][]*unstructured.Unstructured
+	if against == liveSnapshotName {
+		// 只与快照中包含的资源比较
+		to = make(map[schema.GroupVersionResource][]*unstructured.Unstructured, len(snapshot.Resources))
+		for _, res := range snapshot.Resources {
+			gvr := res.GroupVersionResource()
+			objects, err := s.getCachedObjects(c.Request.Context(), gvr, snapshot.Namespace)
+			if err != nil {
+				klog.Errorf("Failed to get live objects for %s: %v", gvr.String(), err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			to[gvr] = objects
+		}
+	} else {
+		_, toLists, err := s.snapshotStore.LoadNamedSnapshot(against)
+		if err != nil {
+			writeSnapshotError(c, err)
+			return
+		}
+		to = listsToObjects(toLists)
+	}
+
+	options := diff.Options{IgnoredFields: append([]string{}, diff.DefaultIgnoredFields...)}
+	for _, field := range strings.Split(c.Query("ignore"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			options.IgnoredFields = append(options.IgnoredFields, field)
+		}
+	}
+
+	report := diff.Compare(from, to, options)
+	report.From = name
+	report.To = against
+	c.JSON(http.StatusOK, report)
+}
+
+// getCachedObjects 通过策略管理器从缓存获取对象
+func (s *Server) getCachedObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ([]*unstructured.Unstructured, error) {
+	namespaced, err := s.isNamespacedResourceCached(gvr)
+	if err != nil {
+		return nil, err
+	}
+	return s.strategyManager.GetObjects(ctx, gvr, namespace, namespaced)
+}
+
+// listsToObjects 将快照中的对象列表转换为指针切片
+func listsToObjects(lists map[schema.GroupVersionResource]*unstructured.UnstructuredList) map[schema.GroupVersionResource][]*unstructured.Unstructured {
+	result := make(map[schema.GroupVersionResource][]*unstructured.Unstructured, len(lists))
+	for gvr, list := range lists {
+		objects := make([]*unstructured.Unstructured, 0, len(list.Items))
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+		result[gvr] = objects
+	}
+	return result
+}
+
+// writeSnapshotError 根据错误类型写入快照接口的错误响应
+func writeSnapshotError(c *gin.Context, err error) {
+	if errors.Is(err, persistence.ErrSnapshotNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	klog.Errorf("Snapshot operation failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
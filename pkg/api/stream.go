@@ -0,0 +1,247 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// streamFlushInterval 每编码多少个元素刷新一次，让客户端尽早收到数据
+	streamFlushInterval = 256
+	// streamBufferSize 写入压缩器或连接前的缓冲大小
+	streamBufferSize = 32 * 1024
+)
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() interface{} {
+			return gzip.NewWriter(io.Discard)
+		},
+	}
+	zstdWriterPool = sync.Pool{
+		New: func() interface{} {
+			// 单个请求内串行编码，不需要额外的并发goroutine
+			encoder, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+			return encoder
+		},
+	}
+)
+
+// negotiateEncoding 根据Accept-Encoding选择压缩方式，优先zstd，其次gzip，q=0表示拒绝
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		accepted[name] = quality > 0
+	}
+
+	for _, encoding := range []string{"zstd", "gzip"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// listStream 以分块传输写入JSON响应，按协商结果压缩
+type listStream struct {
+	c          *gin.Context
+	buffer     *bufio.Writer
+	compressor io.WriteCloser
+	release    func()
+	scratch    bytes.Buffer
+	encoder    *json.Encoder
+}
+
+// newListStream 写入响应头并准备编码器
+func newListStream(c *gin.Context) *listStream {
+	stream := &listStream{c: c}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Vary", "Accept-Encoding")
+
+	var out io.Writer = c.Writer
+	switch negotiateEncoding(c.GetHeader("Accept-Encoding")) {
+	case "zstd":
+		encoder := zstdWriterPool.Get().(*zstd.Encoder)
+		encoder.Reset(c.Writer)
+		stream.compressor = encoder
+		stream.release = func() { zstdWriterPool.Put(encoder) }
+		c.Header("Content-Encoding", "zstd")
+		out = encoder
+	case "gzip":
+		writer := gzipWriterPool.Get().(*gzip.Writer)
+		writer.Reset(c.Writer)
+		stream.compressor = writer
+		stream.release = func() { gzipWriterPool.Put(writer) }
+		c.Header("Content-Encoding", "gzip")
+		out = writer
+	}
+
+	c.Status(http.StatusOK)
+	stream.buffer = bufio.NewWriterSize(out, streamBufferSize)
+	stream.encoder = json.NewEncoder(&stream.scratch)
+	return stream
+}
+
+// raw 写入JSON片段
+func (s *listStream) raw(data string) error {
+	_, err := s.buffer.WriteString(data)
+	return err
+}
+
+// value 编码单个值，复用暂存缓冲区并去掉Encoder追加的换行
+func (s *listStream) value(v interface{}) error {
+	s.scratch.Reset()
+	if err := s.encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := s.buffer.Write(bytes.TrimSuffix(s.scratch.Bytes(), []byte("\n")))
+	return err
+}
+
+// flush 将已编码的数据推送给客户端
+func (s *listStream) flush() error {
+	if err := s.buffer.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := s.compressor.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// close 结束压缩流并归还压缩器
+func (s *listStream) close() error {
+	err := s.buffer.Flush()
+	if s.compressor != nil {
+		if closeErr := s.compressor.Close(); err == nil {
+			err = closeErr
+		}
+		s.release()
+	}
+	return err
+}
+
+// abort 中途出错时结束响应：不写入压缩流的结尾，并在支持时直接关闭连接，
+// 分块传输因此没有结束块，客户端看到的是异常中断的响应，而不是格式完整但内容被截断的响应体
+func (s *listStream) abort() {
+	if s.compressor != nil {
+		s.release()
+	}
+	hijacker, ok := s.c.Writer.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		klog.V(2).Infof("Failed to abort streaming %s: %v", s.c.Request.URL.Path, err)
+		return
+	}
+	conn.Close()
+}
+
+// writeJSONList 逐个编码列表元素并以分块传输写入响应，不在内存中构建整个响应体
+// envelope为空时输出JSON数组，否则输出包含envelope字段和itemsKey数组的对象；
+// 客户端断开或写入失败时中止，不再写入剩余元素，响应以连接中断结束
+func writeJSONList[T any](c *gin.Context, items []T, itemsKey string, envelope gin.H) {
+	stream := newListStream(c)
+	ctx := c.Request.Context()
+
+	err := func() error {
+		if envelope != nil {
+			keys := make([]string, 0, len(envelope))
+			for key := range envelope {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			if err := stream.raw("{"); err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := stream.value(key); err != nil {
+					return err
+				}
+				if err := stream.raw(":"); err != nil {
+					return err
+				}
+				if err := stream.value(envelope[key]); err != nil {
+					return err
+				}
+				if err := stream.raw(","); err != nil {
+					return err
+				}
+			}
+			if err := stream.value(itemsKey); err != nil {
+				return err
+			}
+			if err := stream.raw(":"); err != nil {
+				return err
+			}
+		}
+
+		if err := stream.raw("["); err != nil {
+			return err
+		}
+		for i, item := range items {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if i > 0 {
+				if err := stream.raw(","); err != nil {
+					return err
+				}
+			}
+			if err := stream.value(item); err != nil {
+				return err
+			}
+			if (i+1)%streamFlushInterval == 0 {
+				if err := stream.flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := stream.raw("]"); err != nil {
+			return err
+		}
+		if envelope != nil {
+			return stream.raw("}")
+		}
+		return nil
+	}()
+
+	if err != nil {
+		klog.V(2).Infof("Aborted streaming %s after client disconnect or write error: %v", c.Request.URL.Path, err)
+		stream.abort()
+		c.Abort()
+		return
+	}
+	if err := stream.close(); err != nil {
+		klog.V(2).Infof("Failed to finish streaming %s: %v", c.Request.URL.Path, err)
+	}
+}
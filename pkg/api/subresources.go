@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getResourceObjectStatus 读取对象的status子资源
+func (s *Server) getResourceObjectStatus(c *gin.Context) {
+	s.getResourceObjectSubresource(c, "status")
+}
+
+// getResourceObjectScale 读取对象的scale子资源（autoscaling/v1 Scale，即HPA看到的副本数和选择器）
+func (s *Server) getResourceObjectScale(c *gin.Context) {
+	s.getResourceObjectSubresource(c, "scale")
+}
+
+// getResourceObjectSubresource 直接从API服务器读取子资源视图，资源未声明该子资源时返回404
+func (s *Server) getResourceObjectSubresource(c *gin.Context, subresource string) {
+	if !s.requireLiveCluster(c) {
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := client.Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{}, subresource)
+	if err != nil {
+		writeAPIError(c, "get "+subresource+" of", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result.Object)
+}
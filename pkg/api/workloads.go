@@ -0,0 +1,249 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// restartedAtAnnotation 与kubectl rollout restart使用相同的注解
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	// revisionAnnotation Deployment控制器记录ReplicaSet修订号的注解
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// restartableResources 支持滚动重启的apps组资源，与kubectl rollout restart相同；
+// ReplicaSet修改Pod模板不会替换已有Pod，不支持
+var restartableResources = map[string]bool{
+	"deployments":  true,
+	"statefulsets": true,
+	"daemonsets":   true,
+}
+
+// scaleRequest 扩缩容请求
+type scaleRequest struct {
+	Replicas *int32 `json:"replicas"`
+}
+
+// rollbackRequest 回滚请求，ToRevision为0时回滚到上一个修订
+type rollbackRequest struct {
+	ToRevision int64 `json:"toRevision"`
+}
+
+// scaleResourceObject 通过scale子资源修改副本数，支持所有带scale子资源的资源（包括CRD）
+func (s *Server) scaleResourceObject(c *gin.Context) {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req scaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Replicas == nil || *req.Replicas < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body must be {\"replicas\": <non-negative integer>}"})
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, *req.Replicas))
+	name := c.Param("name")
+	result, err := client.Patch(c.Request.Context(), name, types.MergePatchType, patch, metav1.PatchOptions{
+		DryRun:       dryRun,
+		FieldManager: c.DefaultQuery("fieldManager", defaultFieldManager),
+	}, "scale")
+	if err != nil {
+		writeAPIError(c, "scale", err)
+		return
+	}
+
+	klog.Infof("Scaled %s %s/%s to %d (dryRun=%v)", gvrFromParams(c).String(), c.Query("namespace"), name, *req.Replicas, dryRun != nil)
+	c.JSON(http.StatusOK, result.Object)
+}
+
+// restartResourceObject 修改Pod模板注解触发滚动重启，与kubectl rollout restart相同
+// 只支持Deployment、StatefulSet和DaemonSet
+func (s *Server) restartResourceObject(c *gin.Context) {
+	gvr := gvrFromParams(c)
+	if gvr.Group != "apps" || !restartableResources[gvr.Resource] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "restart is only supported for apps deployments, statefulsets and daemonsets"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := s.resourceClient(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 补丁只添加一个注解，合并补丁与策略合并补丁结果相同
+	name := c.Param("name")
+	result, err := client.Patch(c.Request.Context(), name, types.MergePatchType, patch, metav1.PatchOptions{
+		DryRun:       dryRun,
+		FieldManager: c.DefaultQuery("fieldManager", defaultFieldManager),
+	})
+	if err != nil {
+		writeAPIError(c, "restart", err)
+		return
+	}
+
+	klog.Infof("Restarted %s %s/%s (dryRun=%v)", gvr.String(), c.Query("namespace"), name, dryRun != nil)
+	c.JSON(http.StatusOK, result.Object)
+}
+
+// rollbackDeployment 将Deployment的Pod模板回滚到指定（默认上一个）ReplicaSet修订
+func (s *Server) rollbackDeployment(c *gin.Context) {
+	gvr := gvrFromParams(c)
+	if gvr.Group != "apps" || gvr.Resource != "deployments" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rollback is only supported for apps deployments"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req rollbackRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil || req.ToRevision < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be {\"toRevision\": <revision>} or empty"})
+			return
+		}
+	}
+
+	namespace := c.Query("namespace")
+	if namespace == "" || namespace == "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	name := c.Param("name")
+	deployment, err := s.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		writeAPIError(c, "get", err)
+		return
+	}
+
+	target, err := s.findRollbackReplicaSet(c, deployment, req.ToRevision)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 与kubectl rollout undo相同，去掉控制器添加的pod-template-hash标签
+	template := target.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": deployment.ResourceVersion},
+		{"op": "replace", "path": "/spec/template", "value": template},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{
+		DryRun:       dryRun,
+		FieldManager: c.DefaultQuery("fieldManager", defaultFieldManager),
+	})
+	if err != nil {
+		writeAPIError(c, "rollback", err)
+		return
+	}
+
+	revision := target.Annotations[revisionAnnotation]
+	klog.Infof("Rolled back deployment %s/%s to revision %s (dryRun=%v)", namespace, name, revision, dryRun != nil)
+	c.JSON(http.StatusOK, gin.H{
+		"rolledBackTo": revision,
+		"replicaSet":   target.Name,
+		"deployment":   result,
+		"dryRun":       dryRun != nil,
+	})
+}
+
+// findRollbackReplicaSet 查找Deployment拥有的指定修订ReplicaSet，revision为0时取当前修订之前的最新修订
+func (s *Server) findRollbackReplicaSet(c *gin.Context, deployment *appsv1.Deployment, revision int64) (*appsv1.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployment selector: %v", err)
+	}
+
+	replicaSets, err := s.clientset.AppsV1().ReplicaSets(deployment.Namespace).List(c.Request.Context(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %v", err)
+	}
+
+	current, _ := strconv.ParseInt(deployment.Annotations[revisionAnnotation], 10, 64)
+
+	var target *appsv1.ReplicaSet
+	var targetRevision int64
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		rsRevision, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if revision > 0 {
+			if rsRevision == revision {
+				return rs, nil
+			}
+			continue
+		}
+		if rsRevision < current && rsRevision > targetRevision {
+			target, targetRevision = rs, rsRevision
+		}
+	}
+
+	if revision > 0 {
+		return nil, fmt.Errorf("revision %d not found", revision)
+	}
+	if target == nil {
+		return nil, fmt.Errorf("no previous revision found for deployment %s/%s", deployment.Namespace, deployment.Name)
+	}
+	return target, nil
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// UserInfo 请求用户信息
+type UserInfo struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// Event 单条审计事件
+type Event struct {
+	Timestamp  time.Time `json:"timestamp"`
+	RequestID  string    `json:"requestID"`
+	Level      Level     `json:"level"`
+	User       UserInfo  `json:"user"`
+	SourceIP   string    `json:"sourceIP"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Action     string    `json:"action"`
+	Group      string    `json:"group,omitempty"`
+	Version    string    `json:"version,omitempty"`
+	Resource   string    `json:"resource,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	DryRun     bool      `json:"dryRun,omitempty"`
+	StatusCode int       `json:"statusCode"`
+	Result     string    `json:"result"`
+	LatencyMs  int64     `json:"latencyMs"`
+
+	// Request及以上级别记录
+	RequestBody json.RawMessage `json:"requestBody,omitempty"`
+	// RequestResponse级别记录
+	ResponseBody json.RawMessage `json:"responseBody,omitempty"`
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"k8s.io/klog/v2"
+)
+
+// Logger 审计日志记录器
+type Logger struct {
+	policy *Policy
+	sinks  []Sink
+}
+
+// NewLogger 创建审计日志记录器
+func NewLogger(policy *Policy, sinks ...Sink) *Logger {
+	return &Logger{policy: policy, sinks: sinks}
+}
+
+// LevelFor 返回事件应使用的审计级别，调用方据此决定是否采集请求和响应体
+func (l *Logger) LevelFor(event *Event) Level {
+	return l.policy.LevelFor(event)
+}
+
+// Log 将事件写入所有输出，event.Level为None时忽略
+func (l *Logger) Log(event *Event) {
+	if event.Level == LevelNone || event.Level == "" {
+		return
+	}
+
+	// 按级别裁剪内容
+	if event.Level.Less(LevelRequest) {
+		event.RequestBody = nil
+	}
+	if event.Level.Less(LevelRequestResponse) {
+		event.ResponseBody = nil
+	}
+
+	for _, sink := range l.sinks {
+		if err := sink.Write(event); err != nil {
+			klog.Warningf("Failed to write audit event %s: %v", event.RequestID, err)
+		}
+	}
+}
+
+// Close 关闭所有输出
+func (l *Logger) Close() {
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil {
+			klog.Warningf("Failed to close audit sink: %v", err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Level 审计级别，与Kubernetes审计策略的级别含义相同
+type Level string
+
+const (
+	// LevelNone 不记录
+	LevelNone Level = "None"
+	// LevelMetadata 只记录元数据（用户、资源、操作、结果）
+	LevelMetadata Level = "Metadata"
+	// LevelRequest 额外记录请求体
+	LevelRequest Level = "Request"
+	// LevelRequestResponse 额外记录请求体和响应体
+	LevelRequestResponse Level = "RequestResponse"
+)
+
+// ordinal 返回级别的顺序，用于比较
+func (l Level) ordinal() int {
+	switch l {
+	case LevelMetadata:
+		return 1
+	case LevelRequest:
+		return 2
+	case LevelRequestResponse:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Less 检查级别是否低于另一个级别
+func (l Level) Less(other Level) bool {
+	return l.ordinal() < other.ordinal()
+}
+
+// ParseLevel 解析审计级别
+func ParseLevel(value string) (Level, error) {
+	switch level := Level(value); level {
+	case LevelNone, LevelMetadata, LevelRequest, LevelRequestResponse:
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid audit level %q", value)
+}
+
+// GroupResources 规则匹配的资源组和资源
+type GroupResources struct {
+	Group     string   `json:"group"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Rule 审计规则，所有非空条件都满足时匹配
+type Rule struct {
+	Level      Level            `json:"level"`
+	Actions    []string         `json:"actions,omitempty"`
+	Resources  []GroupResources `json:"resources,omitempty"`
+	Namespaces []string         `json:"namespaces,omitempty"`
+	Users      []string         `json:"users,omitempty"`
+}
+
+// Policy 审计策略，按顺序匹配规则，第一个匹配的规则决定级别
+type Policy struct {
+	// Level 没有规则匹配时的默认级别
+	Level Level  `json:"level"`
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// DefaultPolicy 只使用默认级别的策略
+func DefaultPolicy(level Level) *Policy {
+	return &Policy{Level: level}
+}
+
+// LoadPolicy 从YAML或JSON文件加载审计策略
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit policy %s: %v", path, err)
+	}
+
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse audit policy %s: %v", path, err)
+	}
+
+	if policy.Level == "" {
+		policy.Level = LevelMetadata
+	}
+	if _, err := ParseLevel(string(policy.Level)); err != nil {
+		return nil, err
+	}
+	for i, rule := range policy.Rules {
+		if _, err := ParseLevel(string(rule.Level)); err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// LevelFor 返回事件应使用的审计级别
+func (p *Policy) LevelFor(event *Event) Level {
+	for _, rule := range p.Rules {
+		if rule.matches(event) {
+			return rule.Level
+		}
+	}
+	return p.Level
+}
+
+// matches 检查规则是否匹配事件
+func (r *Rule) matches(event *Event) bool {
+	if len(r.Actions) > 0 && !containsOrWildcard(r.Actions, event.Action) {
+		return false
+	}
+	if len(r.Namespaces) > 0 && !containsOrWildcard(r.Namespaces, event.Namespace) {
+		return false
+	}
+	if len(r.Users) > 0 && !containsOrWildcard(r.Users, event.User.Username) {
+		return false
+	}
+	if len(r.Resources) > 0 {
+		matched := false
+		for _, gr := range r.Resources {
+			if (gr.Group == "*" || gr.Group == event.Group) &&
+				(len(gr.Resources) == 0 || containsOrWildcard(gr.Resources, event.Resource)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// containsOrWildcard 检查列表是否包含指定值或通配符
+func containsOrWildcard(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
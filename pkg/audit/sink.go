@@ -0,0 +1,222 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Sink 审计事件输出
+type Sink interface {
+	// Write 写入单条事件
+	Write(event *Event) error
+	// Close 刷新并关闭输出
+	Close() error
+}
+
+// FileSink 按大小轮转的JSON Lines文件输出
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// NewFileSink 创建文件输出，maxSizeMB为单个文件最大大小，maxBackups为保留的轮转文件数
+func NewFileSink(path string, maxSizeMB, maxBackups int) (*FileSink, error) {
+	sink := &FileSink{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// open 打开（追加）审计文件
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %v", s.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate 轮转文件：path.N-1 -> path.N ... path -> path.1
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if s.maxBackups > 0 {
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+// Write 写入单条事件
+func (s *FileSink) Write(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.maxSize > 0 && s.size+int64(len(data)) > s.maxSize && s.size > 0 {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %v", err)
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// Close 关闭文件
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+// WebhookSink 批量POST到HTTP端点的输出，发送在后台进行，缓冲区满时丢弃事件
+type WebhookSink struct {
+	url       string
+	client    *http.Client
+	events    chan *Event
+	batchSize int
+	done      chan struct{}
+
+	// 关闭后仍在运行的请求可能继续写入，closed防止向已关闭的events发送
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewWebhookSink 创建webhook输出
+func NewWebhookSink(url string) *WebhookSink {
+	sink := &WebhookSink{
+		url:       url,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		events:    make(chan *Event, 1000),
+		batchSize: 100,
+		done:      make(chan struct{}),
+	}
+	go sink.run()
+	return sink
+}
+
+// Write 将事件放入发送缓冲区
+func (s *WebhookSink) Write(event *Event) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.closed {
+		return fmt.Errorf("audit webhook is closed, dropping event %s", event.RequestID)
+	}
+
+	select {
+	case s.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("audit webhook buffer full, dropping event %s", event.RequestID)
+	}
+}
+
+// run 按批次或每秒发送事件
+func (s *WebhookSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := make([]*Event, 0, s.batchSize)
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				s.send(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= s.batchSize {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// send 发送一批事件
+func (s *WebhookSink) send(batch []*Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		klog.Errorf("Failed to encode audit events: %v", err)
+		return
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		klog.Warningf("Failed to send %d audit events to webhook: %v", len(batch), err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		klog.Warningf("Audit webhook returned %d for %d events", resp.StatusCode, len(batch))
+	}
+}
+
+// Close 发送剩余事件后关闭
+func (s *WebhookSink) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.events)
+	s.mutex.Unlock()
+
+	<-s.done
+	return nil
+}
@@ -0,0 +1,139 @@
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// nodeRoleLabelPrefix 节点角色标签前缀
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// NodePod 节点上的Pod
+type NodePod struct {
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Phase     corev1.PodPhase     `json:"phase"`
+	Owner     Owner               `json:"owner"`
+	Requests  corev1.ResourceList `json:"requests"`
+	Limits    corev1.ResourceList `json:"limits"`
+}
+
+// NodeView 节点及其上Pod的资源占用
+type NodeView struct {
+	Name          string                 `json:"name"`
+	Roles         []string               `json:"roles"`
+	Ready         bool                   `json:"ready"`
+	Unschedulable bool                   `json:"unschedulable"` // 已cordon
+	Taints        []corev1.Taint         `json:"taints"`
+	Conditions    []corev1.NodeCondition `json:"conditions"`
+	Capacity      corev1.ResourceList    `json:"capacity"`
+	Allocatable   corev1.ResourceList    `json:"allocatable"`
+	*Totals
+	// RequestsPercent 请求量占可分配量的百分比（包括pods数量）
+	RequestsPercent map[corev1.ResourceName]float64 `json:"requestsPercent"`
+	// LimitsPercent 限制量占可分配量的百分比，超过100表示超卖
+	LimitsPercent map[corev1.ResourceName]float64 `json:"limitsPercent"`
+	PodList       []NodePod                       `json:"podList,omitempty"`
+}
+
+// ToNode 将缓存中的非结构化对象转换为Node
+func ToNode(obj *unstructured.Unstructured) (*corev1.Node, error) {
+	node := &corev1.Node{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, node); err != nil {
+		return nil, fmt.Errorf("failed to convert node %s: %v", obj.GetName(), err)
+	}
+	return node, nil
+}
+
+// BuildNodeView 汇总节点上未结束Pod的资源请求和限制，并与节点的可分配量对比
+// withPods为true时返回节点上的Pod列表（包括已结束的Pod）
+func BuildNodeView(node *corev1.Node, pods []*unstructured.Unstructured, withPods bool) NodeView {
+	view := NodeView{
+		Name:            node.Name,
+		Roles:           []string{},
+		Unschedulable:   node.Spec.Unschedulable,
+		Taints:          node.Spec.Taints,
+		Conditions:      node.Status.Conditions,
+		Capacity:        node.Status.Capacity,
+		Allocatable:     node.Status.Allocatable,
+		Totals:          NewTotals(),
+		RequestsPercent: make(map[corev1.ResourceName]float64),
+		LimitsPercent:   make(map[corev1.ResourceName]float64),
+	}
+	if view.Taints == nil {
+		view.Taints = []corev1.Taint{}
+	}
+	if view.Conditions == nil {
+		view.Conditions = []corev1.NodeCondition{}
+	}
+
+	for label := range node.Labels {
+		if role := strings.TrimPrefix(label, nodeRoleLabelPrefix); role != label && role != "" {
+			view.Roles = append(view.Roles, role)
+		}
+	}
+	sort.Strings(view.Roles)
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			view.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	for _, obj := range pods {
+		pod, err := ToPod(obj)
+		if err != nil {
+			klog.V(4).Infof("Skipping pod in node view: %v", err)
+			continue
+		}
+		if !IsTerminated(pod) {
+			view.Totals.AddPod(pod)
+		}
+		if withPods {
+			requests, limits := PodRequestsAndLimits(pod)
+			view.PodList = append(view.PodList, NodePod{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Phase:     pod.Status.Phase,
+				Owner:     PodOwner(pod),
+				Requests:  requests,
+				Limits:    limits,
+			})
+		}
+	}
+	sort.Slice(view.PodList, func(i, j int) bool {
+		if view.PodList[i].Namespace != view.PodList[j].Namespace {
+			return view.PodList[i].Namespace < view.PodList[j].Namespace
+		}
+		return view.PodList[i].Name < view.PodList[j].Name
+	})
+
+	for name, allocatable := range view.Allocatable {
+		if name == corev1.ResourcePods {
+			if percent := Percent(*resource.NewQuantity(int64(view.Totals.Pods), resource.DecimalSI), allocatable); percent >= 0 {
+				view.RequestsPercent[name] = math.Round(percent*10) / 10
+			}
+			continue
+		}
+		if requested, ok := view.Totals.Requests[name]; ok {
+			if percent := Percent(requested, allocatable); percent >= 0 {
+				view.RequestsPercent[name] = math.Round(percent*10) / 10
+			}
+		}
+		if limited, ok := view.Totals.Limits[name]; ok {
+			if percent := Percent(limited, allocatable); percent >= 0 {
+				view.LimitsPercent[name] = math.Round(percent*10) / 10
+			}
+		}
+	}
+
+	return view
+}
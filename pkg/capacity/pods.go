@@ -0,0 +1,166 @@
+package capacity
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// podTemplateHashLabel Deployment控制器添加到ReplicaSet和Pod上的标签
+const podTemplateHashLabel = "pod-template-hash"
+
+// ToPod 将缓存中的非结构化对象转换为Pod
+func ToPod(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, pod); err != nil {
+		return nil, fmt.Errorf("failed to convert pod %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return pod, nil
+}
+
+// IsTerminated 检查Pod是否已结束，已结束的Pod不再占用资源
+func IsTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// PodRequestsAndLimits 计算Pod的有效资源请求和限制，与调度器的计算方式相同：
+// max(所有容器与sidecar之和, 任一init容器与在它之前启动的sidecar之和) + overhead
+func PodRequestsAndLimits(pod *corev1.Pod) (corev1.ResourceList, corev1.ResourceList) {
+	requests := podResources(pod, func(resources corev1.ResourceRequirements) corev1.ResourceList { return resources.Requests })
+	limits := podResources(pod, func(resources corev1.ResourceRequirements) corev1.ResourceList { return resources.Limits })
+
+	if pod.Spec.Overhead != nil {
+		addResourceList(requests, pod.Spec.Overhead)
+		for name, quantity := range pod.Spec.Overhead {
+			// 只为已设置限制的资源累加overhead
+			if value, ok := limits[name]; ok {
+				value.Add(quantity)
+				limits[name] = value
+			}
+		}
+	}
+
+	return requests, limits
+}
+
+// podResources 按调度器的规则汇总容器资源，不含overhead
+// restartPolicy为Always的init容器（sidecar）在Pod整个生命周期内运行，累加到总量中；
+// 普通init容器依次运行，占用的是自身与之前已启动的sidecar之和
+func podResources(pod *corev1.Pod, get func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResourceList(total, get(container.Resources))
+	}
+
+	sidecars := corev1.ResourceList{}
+	initMax := corev1.ResourceList{}
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResourceList(total, get(container.Resources))
+			addResourceList(sidecars, get(container.Resources))
+			maxResourceList(initMax, sidecars)
+			continue
+		}
+
+		running := corev1.ResourceList{}
+		addResourceList(running, get(container.Resources))
+		addResourceList(running, sidecars)
+		maxResourceList(initMax, running)
+	}
+
+	maxResourceList(total, initMax)
+	return total
+}
+
+// addResourceList 将b累加到a
+func addResourceList(a, b corev1.ResourceList) {
+	for name, quantity := range b {
+		if value, ok := a[name]; ok {
+			value.Add(quantity)
+			a[name] = value
+		} else {
+			a[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+// maxResourceList 将a中每项设置为a和b中的较大值
+func maxResourceList(a, b corev1.ResourceList) {
+	for name, quantity := range b {
+		if value, ok := a[name]; !ok || quantity.Cmp(value) > 0 {
+			a[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+// Owner 拥有Pod的工作负载
+type Owner struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+}
+
+// PodOwner 返回Pod的顶层工作负载
+// ReplicaSet根据pod-template-hash推断所属的Deployment，不需要额外读取ReplicaSet；没有控制器时返回Pod本身
+func PodOwner(pod *corev1.Pod) Owner {
+	controller := metav1.GetControllerOf(pod)
+	if controller == nil {
+		return Owner{Namespace: pod.Namespace, Kind: "Pod", Name: pod.Name}
+	}
+
+	if controller.Kind == "ReplicaSet" {
+		if hash := pod.Labels[podTemplateHashLabel]; hash != "" && strings.HasSuffix(controller.Name, "-"+hash) {
+			return Owner{Namespace: pod.Namespace, Kind: "Deployment", Name: strings.TrimSuffix(controller.Name, "-"+hash)}
+		}
+	}
+	return Owner{Namespace: pod.Namespace, Kind: controller.Kind, Name: controller.Name}
+}
+
+// Totals 一组Pod的资源汇总
+type Totals struct {
+	Pods                      int                 `json:"pods"`
+	Containers                int                 `json:"containers"`
+	ContainersWithoutRequests int                 `json:"containersWithoutRequests"`
+	ContainersWithoutLimits   int                 `json:"containersWithoutLimits"`
+	Requests                  corev1.ResourceList `json:"requests"`
+	Limits                    corev1.ResourceList `json:"limits"`
+}
+
+// NewTotals 创建空的汇总
+func NewTotals() *Totals {
+	return &Totals{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+}
+
+// AddPod 累加Pod的资源请求和限制
+func (t *Totals) AddPod(pod *corev1.Pod) {
+	requests, limits := PodRequestsAndLimits(pod)
+	addResourceList(t.Requests, requests)
+	addResourceList(t.Limits, limits)
+
+	t.Pods++
+	for _, container := range pod.Spec.Containers {
+		t.Containers++
+		if len(container.Resources.Requests) == 0 {
+			t.ContainersWithoutRequests++
+		}
+		if len(container.Resources.Limits) == 0 {
+			t.ContainersWithoutLimits++
+		}
+	}
+}
+
+// Percent 计算used占total的百分比，total为零时返回-1
+func Percent(used, total resource.Quantity) float64 {
+	if total.IsZero() {
+		return -1
+	}
+	return float64(used.MilliValue()) / float64(total.MilliValue()) * 100
+}
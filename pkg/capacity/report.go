@@ -0,0 +1,256 @@
+package capacity
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// QuotaUsage ResourceQuota与计算出的Pod汇总的对比
+type QuotaUsage struct {
+	Name string              `json:"name"`
+	Hard corev1.ResourceList `json:"hard"`
+	// Used 配额控制器记录的已用量
+	Used corev1.ResourceList `json:"used"`
+	// Computed 从缓存中的Pod计算出的对应用量（requests.cpu、limits.memory、pods等）
+	Computed corev1.ResourceList `json:"computed"`
+	// Utilization 计算用量占硬限制的百分比
+	Utilization map[corev1.ResourceName]float64 `json:"utilization"`
+}
+
+// LimitViolation 超出LimitRange最大值或低于最小值的容器
+type LimitViolation struct {
+	Pod       string              `json:"pod"`
+	Container string              `json:"container"`
+	Resource  corev1.ResourceName `json:"resource"`
+	Field     string              `json:"field"`
+	Value     string              `json:"value"`
+	Bound     string              `json:"bound"`
+	Type      string              `json:"type"`
+}
+
+// LimitRangeSummary LimitRange中的容器级限制
+type LimitRangeSummary struct {
+	Name   string                  `json:"name"`
+	Limits []corev1.LimitRangeItem `json:"limits"`
+	Issues []LimitViolation        `json:"violations"`
+}
+
+// NamespaceReport 命名空间的资源报告
+type NamespaceReport struct {
+	*Totals
+	Quotas      []QuotaUsage        `json:"quotas"`
+	LimitRanges []LimitRangeSummary `json:"limitRanges"`
+}
+
+// OwnerReport 工作负载的资源汇总
+type OwnerReport struct {
+	Owner
+	*Totals
+}
+
+// Report 容量报告
+type Report struct {
+	Cluster    *Totals                     `json:"cluster"`
+	Namespaces map[string]*NamespaceReport `json:"namespaces"`
+	Nodes      map[string]*Totals          `json:"nodes"`
+	Owners     []OwnerReport               `json:"owners"`
+}
+
+// BuildReport 按命名空间、节点和工作负载汇总Pod的资源请求和限制，
+// 并与ResourceQuota和LimitRange对比；已结束的Pod不计入
+func BuildReport(pods, quotas, limitRanges []*unstructured.Unstructured) *Report {
+	report := &Report{
+		Cluster:    NewTotals(),
+		Namespaces: make(map[string]*NamespaceReport),
+		Nodes:      make(map[string]*Totals),
+		Owners:     []OwnerReport{},
+	}
+	owners := make(map[Owner]*Totals)
+	var activePods []*corev1.Pod
+
+	for _, obj := range pods {
+		pod, err := ToPod(obj)
+		if err != nil {
+			klog.V(4).Infof("Skipping pod in capacity report: %v", err)
+			continue
+		}
+		if IsTerminated(pod) {
+			continue
+		}
+		activePods = append(activePods, pod)
+
+		report.Cluster.AddPod(pod)
+		report.namespace(pod.Namespace).AddPod(pod)
+
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			nodeName = "<unscheduled>"
+		}
+		if _, ok := report.Nodes[nodeName]; !ok {
+			report.Nodes[nodeName] = NewTotals()
+		}
+		report.Nodes[nodeName].AddPod(pod)
+
+		owner := PodOwner(pod)
+		if _, ok := owners[owner]; !ok {
+			owners[owner] = NewTotals()
+		}
+		owners[owner].AddPod(pod)
+	}
+
+	for owner, totals := range owners {
+		report.Owners = append(report.Owners, OwnerReport{Owner: owner, Totals: totals})
+	}
+	sort.Slice(report.Owners, func(i, j int) bool {
+		a, b := report.Owners[i], report.Owners[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	for _, obj := range quotas {
+		quota := &corev1.ResourceQuota{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, quota); err != nil {
+			klog.V(4).Infof("Skipping resource quota %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		ns := report.namespace(quota.Namespace)
+		ns.Quotas = append(ns.Quotas, quotaUsage(quota, ns.Totals))
+	}
+
+	for _, obj := range limitRanges {
+		limitRange := &corev1.LimitRange{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, limitRange); err != nil {
+			klog.V(4).Infof("Skipping limit range %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		summary := LimitRangeSummary{
+			Name:   limitRange.Name,
+			Limits: limitRange.Spec.Limits,
+			Issues: []LimitViolation{},
+		}
+		for _, pod := range activePods {
+			if pod.Namespace == limitRange.Namespace {
+				summary.Issues = append(summary.Issues, limitViolations(pod, limitRange.Spec.Limits)...)
+			}
+		}
+		ns := report.namespace(limitRange.Namespace)
+		ns.LimitRanges = append(ns.LimitRanges, summary)
+	}
+
+	return report
+}
+
+// namespace 返回（必要时创建）命名空间报告
+func (r *Report) namespace(name string) *NamespaceReport {
+	ns, ok := r.Namespaces[name]
+	if !ok {
+		ns = &NamespaceReport{
+			Totals:      NewTotals(),
+			Quotas:      []QuotaUsage{},
+			LimitRanges: []LimitRangeSummary{},
+		}
+		r.Namespaces[name] = ns
+	}
+	return ns
+}
+
+// quotaUsage 计算配额中每项硬限制对应的Pod用量
+func quotaUsage(quota *corev1.ResourceQuota, totals *Totals) QuotaUsage {
+	usage := QuotaUsage{
+		Name:        quota.Name,
+		Hard:        quota.Status.Hard,
+		Used:        quota.Status.Used,
+		Computed:    corev1.ResourceList{},
+		Utilization: make(map[corev1.ResourceName]float64),
+	}
+	if len(usage.Hard) == 0 {
+		usage.Hard = quota.Spec.Hard
+	}
+
+	for name, hard := range usage.Hard {
+		computed, ok := computedQuotaValue(name, totals)
+		if !ok {
+			continue
+		}
+		usage.Computed[name] = computed
+		if percent := Percent(computed, hard); percent >= 0 {
+			usage.Utilization[name] = math.Round(percent*10) / 10
+		}
+	}
+	return usage
+}
+
+// computedQuotaValue 返回配额资源名对应的Pod汇总值，cpu和memory等同于requests.*
+func computedQuotaValue(name corev1.ResourceName, totals *Totals) (resource.Quantity, bool) {
+	switch name {
+	case corev1.ResourcePods:
+		return *resource.NewQuantity(int64(totals.Pods), resource.DecimalSI), true
+	case corev1.ResourceCPU, corev1.ResourceRequestsCPU:
+		return quantityOf(totals.Requests, corev1.ResourceCPU), true
+	case corev1.ResourceMemory, corev1.ResourceRequestsMemory:
+		return quantityOf(totals.Requests, corev1.ResourceMemory), true
+	case corev1.ResourceEphemeralStorage, corev1.ResourceRequestsEphemeralStorage:
+		return quantityOf(totals.Requests, corev1.ResourceEphemeralStorage), true
+	case corev1.ResourceLimitsCPU:
+		return quantityOf(totals.Limits, corev1.ResourceCPU), true
+	case corev1.ResourceLimitsMemory:
+		return quantityOf(totals.Limits, corev1.ResourceMemory), true
+	case corev1.ResourceLimitsEphemeralStorage:
+		return quantityOf(totals.Limits, corev1.ResourceEphemeralStorage), true
+	}
+	return resource.Quantity{}, false
+}
+
+// quantityOf 返回资源列表中的值，不存在时返回零值
+func quantityOf(list corev1.ResourceList, name corev1.ResourceName) resource.Quantity {
+	if quantity, ok := list[name]; ok {
+		return quantity
+	}
+	return resource.Quantity{}
+}
+
+// limitViolations 检查Pod中的容器是否超出LimitRange的容器级最大值或低于最小值
+func limitViolations(pod *corev1.Pod, limits []corev1.LimitRangeItem) []LimitViolation {
+	var violations []LimitViolation
+	for _, item := range limits {
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			for name, max := range item.Max {
+				for _, field := range []string{"requests", "limits"} {
+					values := container.Resources.Requests
+					if field == "limits" {
+						values = container.Resources.Limits
+					}
+					if value, ok := values[name]; ok && value.Cmp(max) > 0 {
+						violations = append(violations, LimitViolation{
+							Pod: pod.Name, Container: container.Name, Resource: name, Field: field,
+							Value: value.String(), Bound: max.String(), Type: "max",
+						})
+					}
+				}
+			}
+			for name, min := range item.Min {
+				if value, ok := container.Resources.Requests[name]; ok && value.Cmp(min) < 0 {
+					violations = append(violations, LimitViolation{
+						Pod: pod.Name, Container: container.Name, Resource: name, Field: "requests",
+						Value: value.String(), Bound: min.String(), Type: "min",
+					})
+				}
+			}
+		}
+	}
+	return violations
+}
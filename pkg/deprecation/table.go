@@ -0,0 +1,208 @@
+package deprecation
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed deprecations.yaml
+var defaultTableData []byte
+
+// defaultTable 内置弃用表
+var defaultTable = mustParse(defaultTableData)
+
+// Entry 一个组版本中被弃用的资源
+type Entry struct {
+	Group        string   `json:"group"`
+	Version      string   `json:"version"`
+	Resources    []string `json:"resources"`
+	DeprecatedIn string   `json:"deprecatedIn,omitempty"`
+	RemovedIn    string   `json:"removedIn,omitempty"`
+	// Replacement 替代的组版本，如apps/v1，为空表示没有替代
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// GroupVersion 返回弃用的组版本字符串
+func (e *Entry) GroupVersion() string {
+	if e.Group == "" {
+		return e.Version
+	}
+	return e.Group + "/" + e.Version
+}
+
+// Exclusion 不在资源列表中展示的资源
+type Exclusion struct {
+	Group     string   `json:"group"`
+	Resources []string `json:"resources"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// Table 弃用表
+type Table struct {
+	Deprecations []Entry     `json:"deprecations"`
+	Exclusions   []Exclusion `json:"exclusions"`
+}
+
+// Default 返回内置弃用表
+func Default() *Table {
+	return defaultTable
+}
+
+// Parse 解析YAML或JSON格式的弃用表
+func Parse(data []byte) (*Table, error) {
+	table := &Table{}
+	if err := yaml.Unmarshal(data, table); err != nil {
+		return nil, fmt.Errorf("failed to parse deprecation table: %v", err)
+	}
+
+	for i, entry := range table.Deprecations {
+		if entry.Version == "" || len(entry.Resources) == 0 {
+			return nil, fmt.Errorf("deprecation %d: version and resources are required", i)
+		}
+		for _, release := range []string{entry.DeprecatedIn, entry.RemovedIn} {
+			if release == "" {
+				continue
+			}
+			if _, err := ParseRelease(release); err != nil {
+				return nil, fmt.Errorf("deprecation %d (%s): %v", i, entry.GroupVersion(), err)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// Load 从文件加载弃用表
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deprecation table %s: %v", path, err)
+	}
+	return Parse(data)
+}
+
+// mustParse 解析内置弃用表，内置数据无效时直接panic
+func mustParse(data []byte) *Table {
+	table, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// IsSuperseded 检查资源版本是否已被弃用，且替代组版本在同一发现结果中提供了同名资源
+// served报告发现结果中的组版本是否提供该资源；没有替代或替代版本未提供时返回false，
+// 避免隐藏集群中唯一提供的版本（如1.26至1.28的flowcontrol v1beta3）
+func (t *Table) IsSuperseded(group, version, resource string, served func(groupVersion, resource string) bool) bool {
+	for i := range t.Deprecations {
+		entry := &t.Deprecations[i]
+		if entry.Group == group && entry.Version == version && contains(entry.Resources, resource) {
+			return entry.Replacement != "" && served(entry.Replacement, resource)
+		}
+	}
+	return false
+}
+
+// ServedResources 将发现结果转换为组版本到资源名的查找函数，供IsSuperseded使用
+func ServedResources(lists []*metav1.APIResourceList) func(groupVersion, resource string) bool {
+	served := make(map[string]map[string]bool, len(lists))
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		resources := make(map[string]bool, len(list.APIResources))
+		for _, r := range list.APIResources {
+			resources[r.Name] = true
+		}
+		served[list.GroupVersion] = resources
+	}
+	return func(groupVersion, resource string) bool {
+		return served[groupVersion][resource]
+	}
+}
+
+// IsExcluded 检查资源是否应从资源列表中排除
+func (t *Table) IsExcluded(group, resource string) bool {
+	for _, exclusion := range t.Exclusions {
+		if exclusion.Group == group && contains(exclusion.Resources, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemovedBetween 返回在current之后、target（含）之前移除的条目，即从current升级到target时失效的API；
+// 在current版本已移除的API不再计入，current为零值时不设下限
+func (t *Table) RemovedBetween(current, target Release) []Entry {
+	var entries []Entry
+	for _, entry := range t.Deprecations {
+		if entry.RemovedIn == "" {
+			continue
+		}
+		removedIn, err := ParseRelease(entry.RemovedIn)
+		if err != nil {
+			continue
+		}
+		if current.Less(removedIn) && !target.Less(removedIn) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// contains 检查列表是否包含指定值
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Release Kubernetes发行版本（主版本.次版本）
+type Release struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+}
+
+// ParseRelease 解析发行版本，支持1.29、v1.29、v1.29.3和v1.29.3-gke.100等格式
+func ParseRelease(value string) (Release, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(value), "v")
+	parts := strings.SplitN(trimmed, ".", 3)
+	if len(parts) < 2 {
+		return Release{}, fmt.Errorf("invalid Kubernetes version %q", value)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Release{}, fmt.Errorf("invalid Kubernetes version %q", value)
+	}
+	// 次版本可能带有后缀，如GKE的"27+"
+	minorText := strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	minor, err := strconv.Atoi(minorText)
+	if err != nil {
+		return Release{}, fmt.Errorf("invalid Kubernetes version %q", value)
+	}
+
+	return Release{Major: major, Minor: minor}, nil
+}
+
+// Less 检查版本是否早于另一个版本
+func (r Release) Less(other Release) bool {
+	if r.Major != other.Major {
+		return r.Major < other.Major
+	}
+	return r.Minor < other.Minor
+}
+
+// String 返回版本字符串
+func (r Release) String() string {
+	return fmt.Sprintf("%d.%d", r.Major, r.Minor)
+}
@@ -0,0 +1,261 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// 字段变化类型
+const (
+	FieldAdded   = "added"
+	FieldRemoved = "removed"
+	FieldChanged = "changed"
+)
+
+// DefaultIgnoredFields 默认忽略的字段路径，这些字段每次写入都会变化
+var DefaultIgnoredFields = []string{
+	"metadata.resourceVersion",
+	"metadata.managedFields",
+	"metadata.generation",
+}
+
+// ObjectRef 对象引用
+type ObjectRef struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// FieldChange 单个字段的变化
+type FieldChange struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ObjectChange 单个对象的字段级变化
+type ObjectChange struct {
+	ObjectRef
+	Fields []FieldChange `json:"fields"`
+}
+
+// ResourceDiff 单个资源的差异
+type ResourceDiff struct {
+	Group    string         `json:"group"`
+	Version  string         `json:"version"`
+	Resource string         `json:"resource"`
+	Added    []ObjectRef    `json:"added"`
+	Removed  []ObjectRef    `json:"removed"`
+	Changed  []ObjectChange `json:"changed"`
+}
+
+// Summary 差异汇总
+type Summary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// Report 两组对象之间的差异报告
+type Report struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Summary   Summary        `json:"summary"`
+	Resources []ResourceDiff `json:"resources"`
+}
+
+// Options 差异比较选项
+type Options struct {
+	// IgnoredFields 忽略的字段路径（点分隔，匹配该路径及其子路径）
+	IgnoredFields []string
+}
+
+// Compare 比较两组按GVR分组的对象，只比较两边都存在或任意一边存在的GVR
+func Compare(from, to map[schema.GroupVersionResource][]*unstructured.Unstructured, options Options) Report {
+	report := Report{Resources: []ResourceDiff{}}
+
+	gvrSet := make(map[schema.GroupVersionResource]struct{})
+	for gvr := range from {
+		gvrSet[gvr] = struct{}{}
+	}
+	for gvr := range to {
+		gvrSet[gvr] = struct{}{}
+	}
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(gvrSet))
+	for gvr := range gvrSet {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+
+	for _, gvr := range gvrs {
+		resourceDiff := compareResource(gvr, from[gvr], to[gvr], options)
+		if len(resourceDiff.Added) == 0 && len(resourceDiff.Removed) == 0 && len(resourceDiff.Changed) == 0 {
+			continue
+		}
+
+		report.Summary.Added += len(resourceDiff.Added)
+		report.Summary.Removed += len(resourceDiff.Removed)
+		report.Summary.Changed += len(resourceDiff.Changed)
+		report.Resources = append(report.Resources, resourceDiff)
+	}
+
+	return report
+}
+
+// compareResource 比较同一资源的两组对象
+func compareResource(gvr schema.GroupVersionResource, from, to []*unstructured.Unstructured, options Options) ResourceDiff {
+	resourceDiff := ResourceDiff{
+		Group:    gvr.Group,
+		Version:  gvr.Version,
+		Resource: gvr.Resource,
+		Added:    []ObjectRef{},
+		Removed:  []ObjectRef{},
+		Changed:  []ObjectChange{},
+	}
+
+	fromIndex := indexObjects(from)
+	toIndex := indexObjects(to)
+
+	for ref, oldObj := range fromIndex {
+		newObj, exists := toIndex[ref]
+		if !exists {
+			resourceDiff.Removed = append(resourceDiff.Removed, ref)
+			continue
+		}
+
+		if fields := Fields(oldObj.Object, newObj.Object, options); len(fields) > 0 {
+			resourceDiff.Changed = append(resourceDiff.Changed, ObjectChange{ObjectRef: ref, Fields: fields})
+		}
+	}
+
+	for ref := range toIndex {
+		if _, exists := fromIndex[ref]; !exists {
+			resourceDiff.Added = append(resourceDiff.Added, ref)
+		}
+	}
+
+	sortRefs(resourceDiff.Added)
+	sortRefs(resourceDiff.Removed)
+	sort.Slice(resourceDiff.Changed, func(i, j int) bool {
+		return refLess(resourceDiff.Changed[i].ObjectRef, resourceDiff.Changed[j].ObjectRef)
+	})
+	return resourceDiff
+}
+
+// Fields 返回两个对象之间的字段级差异
+func Fields(oldObj, newObj map[string]interface{}, options Options) []FieldChange {
+	var changes []FieldChange
+	compareValues("", oldObj, newObj, options, &changes)
+	return changes
+}
+
+// compareValues 递归比较两个JSON值
+func compareValues(path string, oldValue, newValue interface{}, options Options, changes *[]FieldChange) {
+	if isIgnored(path, options.IgnoredFields) {
+		return
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]struct{}, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = struct{}{}
+		}
+		for k := range newMap {
+			keys[k] = struct{}{}
+		}
+
+		sortedKeys := make([]string, 0, len(keys))
+		for k := range keys {
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, k := range sortedKeys {
+			childPath := joinPath(path, k)
+			oldChild, inOld := oldMap[k]
+			newChild, inNew := newMap[k]
+			switch {
+			case !inOld:
+				if !isIgnored(childPath, options.IgnoredFields) {
+					*changes = append(*changes, FieldChange{Path: childPath, Type: FieldAdded, New: newChild})
+				}
+			case !inNew:
+				if !isIgnored(childPath, options.IgnoredFields) {
+					*changes = append(*changes, FieldChange{Path: childPath, Type: FieldRemoved, Old: oldChild})
+				}
+			default:
+				compareValues(childPath, oldChild, newChild, options, changes)
+			}
+		}
+		return
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList {
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldList):
+				*changes = append(*changes, FieldChange{Path: childPath, Type: FieldAdded, New: newList[i]})
+			case i >= len(newList):
+				*changes = append(*changes, FieldChange{Path: childPath, Type: FieldRemoved, Old: oldList[i]})
+			default:
+				compareValues(childPath, oldList[i], newList[i], options, changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*changes = append(*changes, FieldChange{Path: path, Type: FieldChanged, Old: oldValue, New: newValue})
+	}
+}
+
+// isIgnored 检查路径是否在忽略列表中（包括子路径）
+func isIgnored(path string, ignored []string) bool {
+	for _, prefix := range ignored {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexObjects 按命名空间和名称索引对象
+func indexObjects(objects []*unstructured.Unstructured) map[ObjectRef]*unstructured.Unstructured {
+	index := make(map[ObjectRef]*unstructured.Unstructured, len(objects))
+	for _, obj := range objects {
+		index[ObjectRef{Namespace: obj.GetNamespace(), Name: obj.GetName()}] = obj
+	}
+	return index
+}
+
+// sortRefs 按命名空间和名称排序
+func sortRefs(refs []ObjectRef) {
+	sort.Slice(refs, func(i, j int) bool { return refLess(refs[i], refs[j]) })
+}
+
+// refLess 比较两个对象引用的顺序
+func refLess(a, b ObjectRef) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
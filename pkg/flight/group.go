@@ -0,0 +1,73 @@
+package flight
+
+import (
+	"context"
+	"sync"
+)
+
+// call 进行中的调用及其等待者数量
+type call[T any] struct {
+	done    chan struct{}
+	value   T
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Group 合并相同key的并发调用，结果在等待者之间共享
+// 与singleflight不同，调用使用独立的上下文：单个等待者离开（如客户端断开）不会中止其他等待者共享的调用，
+// 所有等待者都离开后才取消调用
+type Group[T any] struct {
+	mutex sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do 执行或加入key对应的调用，返回结果以及是否与其他调用方共享
+// fn收到的上下文保留第一个调用方上下文中的值，在调用完成或所有等待者的ctx都取消后取消
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mutex.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, shared, c.err
+	case <-ctx.Done():
+		g.mutex.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// 最后一个等待者离开，取消调用；之后的调用方重新开始，不加入已取消的调用
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mutex.Unlock()
+
+		var zero T
+		return zero, shared, ctx.Err()
+	}
+}
+
+// run 执行调用并通知所有等待者
+func (g *Group[T]) run(ctx context.Context, key string, c *call[T], fn func(ctx context.Context) (T, error)) {
+	defer c.cancel()
+	c.value, c.err = fn(ctx)
+
+	g.mutex.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mutex.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,191 @@
+package informer
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxMissingLabelObjects 报告中列出的缺少必需标签的对象数上限
+const maxMissingLabelObjects = 500
+
+// ValueCount 标签值及其出现次数
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// LabelKeyStats 单个标签键的统计
+type LabelKeyStats struct {
+	Key         string       `json:"key"`
+	Objects     int          `json:"objects"`
+	Cardinality int          `json:"cardinality"`
+	TopValues   []ValueCount `json:"topValues,omitempty"`
+}
+
+// AnnotationKeyStats 单个注解键的统计（注解值通常很大，只统计键）
+type AnnotationKeyStats struct {
+	Key     string `json:"key"`
+	Objects int    `json:"objects"`
+}
+
+// MissingLabels 缺少必需标签的对象
+type MissingLabels struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Missing   []string `json:"missing"`
+}
+
+// LabelStats 一组对象的标签和注解统计
+type LabelStats struct {
+	Objects     int                  `json:"objects"`
+	Labels      []LabelKeyStats      `json:"labels"`
+	Annotations []AnnotationKeyStats `json:"annotations"`
+}
+
+// LabelReport 资源的标签分析报告，包括整体和按命名空间的统计
+type LabelReport struct {
+	LabelStats
+	Namespaces      map[string]LabelStats `json:"namespaces,omitempty"`
+	MissingRequired []MissingLabels       `json:"missingRequired"`
+	MissingCount    int                   `json:"missingCount"`
+}
+
+// labelAccumulator 统计累加器
+type labelAccumulator struct {
+	objects     int
+	labels      map[string]map[string]int
+	annotations map[string]int
+}
+
+func newLabelAccumulator() *labelAccumulator {
+	return &labelAccumulator{
+		labels:      make(map[string]map[string]int),
+		annotations: make(map[string]int),
+	}
+}
+
+// add 累加单个对象
+func (a *labelAccumulator) add(obj *unstructured.Unstructured) {
+	a.objects++
+	for key, value := range obj.GetLabels() {
+		values, ok := a.labels[key]
+		if !ok {
+			values = make(map[string]int)
+			a.labels[key] = values
+		}
+		values[value]++
+	}
+	for key := range obj.GetAnnotations() {
+		a.annotations[key]++
+	}
+}
+
+// stats 生成统计结果，topValues为每个标签键保留的最常见值数量
+func (a *labelAccumulator) stats(topValues int) LabelStats {
+	stats := LabelStats{
+		Objects:     a.objects,
+		Labels:      make([]LabelKeyStats, 0, len(a.labels)),
+		Annotations: make([]AnnotationKeyStats, 0, len(a.annotations)),
+	}
+
+	for key, values := range a.labels {
+		keyStats := LabelKeyStats{Key: key, Cardinality: len(values)}
+		counts := make([]ValueCount, 0, len(values))
+		for value, count := range values {
+			keyStats.Objects += count
+			counts = append(counts, ValueCount{Value: value, Count: count})
+		}
+		if topValues > 0 {
+			sort.Slice(counts, func(i, j int) bool {
+				if counts[i].Count != counts[j].Count {
+					return counts[i].Count > counts[j].Count
+				}
+				return counts[i].Value < counts[j].Value
+			})
+			if len(counts) > topValues {
+				counts = counts[:topValues]
+			}
+			keyStats.TopValues = counts
+		}
+		stats.Labels = append(stats.Labels, keyStats)
+	}
+	sort.Slice(stats.Labels, func(i, j int) bool {
+		if stats.Labels[i].Objects != stats.Labels[j].Objects {
+			return stats.Labels[i].Objects > stats.Labels[j].Objects
+		}
+		return stats.Labels[i].Key < stats.Labels[j].Key
+	})
+
+	for key, count := range a.annotations {
+		stats.Annotations = append(stats.Annotations, AnnotationKeyStats{Key: key, Objects: count})
+	}
+	sort.Slice(stats.Annotations, func(i, j int) bool {
+		if stats.Annotations[i].Objects != stats.Annotations[j].Objects {
+			return stats.Annotations[i].Objects > stats.Annotations[j].Objects
+		}
+		return stats.Annotations[i].Key < stats.Annotations[j].Key
+	})
+
+	return stats
+}
+
+// AggregateLabels 统计对象的标签键、值、基数和注解键（整体和按命名空间），
+// 并找出缺少required中任一标签的对象
+func AggregateLabels(objects []*unstructured.Unstructured, required []string, topValues int) LabelReport {
+	total := newLabelAccumulator()
+	namespaces := make(map[string]*labelAccumulator)
+
+	report := LabelReport{MissingRequired: []MissingLabels{}}
+	for _, obj := range objects {
+		total.add(obj)
+
+		// 与GetNamespaces相同，集群级对象不计入命名空间统计
+		if ns := obj.GetNamespace(); ns != "" {
+			acc, ok := namespaces[ns]
+			if !ok {
+				acc = newLabelAccumulator()
+				namespaces[ns] = acc
+			}
+			acc.add(obj)
+		}
+
+		if len(required) == 0 {
+			continue
+		}
+		labels := obj.GetLabels()
+		var missing []string
+		for _, key := range required {
+			if _, ok := labels[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			report.MissingCount++
+			if len(report.MissingRequired) < maxMissingLabelObjects {
+				report.MissingRequired = append(report.MissingRequired, MissingLabels{
+					Namespace: obj.GetNamespace(),
+					Name:      obj.GetName(),
+					Missing:   missing,
+				})
+			}
+		}
+	}
+
+	report.LabelStats = total.stats(topValues)
+	if len(namespaces) > 0 {
+		report.Namespaces = make(map[string]LabelStats, len(namespaces))
+		for ns, acc := range namespaces {
+			// 命名空间统计只保留键和基数，避免报告过大
+			report.Namespaces[ns] = acc.stats(0)
+		}
+	}
+
+	sort.Slice(report.MissingRequired, func(i, j int) bool {
+		if report.MissingRequired[i].Namespace != report.MissingRequired[j].Namespace {
+			return report.MissingRequired[i].Namespace < report.MissingRequired[j].Namespace
+		}
+		return report.MissingRequired[i].Name < report.MissingRequired[j].Name
+	})
+	return report
+}
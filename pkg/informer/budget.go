@@ -0,0 +1,133 @@
+package informer
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// startMemoryBudgetEnforcement 定期检查Informer缓存的估算内存是否超出预算
+func (sm *StrategyManager) startMemoryBudgetEnforcement() {
+	ticker := time.NewTicker(sm.strategy.MemoryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sm.ctx.Done():
+			return
+		case <-ticker.C:
+			sm.enforceMemoryBudget()
+		}
+	}
+}
+
+// enforceMemoryBudget 超出预算时按最近最少使用的顺序驱逐非预加载、非固定的Informer，
+// 被驱逐资源的请求改为直接分页LIST
+func (sm *StrategyManager) enforceMemoryBudget() {
+	reporter, ok := sm.resourceCache.(MemoryReporter)
+	budget := sm.strategy.MemoryBudgetBytes
+	if !ok || budget <= 0 {
+		return
+	}
+	total := reporter.TotalMemoryBytes()
+	if total <= budget {
+		return
+	}
+
+	type candidate struct {
+		gvr        schema.GroupVersionResource
+		lastAccess time.Time
+		size       int64
+	}
+	var candidates []candidate
+	preloaded := sm.preloadedSet()
+	sm.accessMutex.RLock()
+	for gvr, lastAccess := range sm.accessTracker {
+		if preloaded[gvr] || sm.pinned[gvr] > 0 {
+			continue
+		}
+		if size := reporter.MemoryBytes(gvr); size > 0 {
+			candidates = append(candidates, candidate{gvr: gvr, lastAccess: lastAccess, size: size})
+		}
+	}
+	sm.accessMutex.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastAccess.Before(candidates[j].lastAccess)
+	})
+
+	for _, c := range candidates {
+		if total <= budget {
+			break
+		}
+		klog.Warningf("Informer memory %d bytes exceeds budget %d bytes, evicting %s (%d bytes)",
+			total, budget, c.gvr.String(), c.size)
+		sm.resourceCache.StopInformer(c.gvr)
+		total -= c.size
+
+		sm.accessMutex.Lock()
+		delete(sm.accessTracker, c.gvr)
+		sm.evicted[c.gvr] = c.size
+		sm.accessMutex.Unlock()
+	}
+
+	if total > budget {
+		klog.Warningf("Informer memory %d bytes still exceeds budget %d bytes, remaining informers are preloaded or pinned",
+			total, budget)
+	}
+}
+
+// evictSyncing 同步中的资源超出剩余预算时的回调，驱逐非预加载、非固定的资源，返回是否已驱逐；
+// 预加载和固定的资源继续同步，由定期检查驱逐其他资源
+func (sm *StrategyManager) evictSyncing(gvr schema.GroupVersionResource, size int64) bool {
+	sm.accessMutex.Lock()
+	if sm.preloadedSet()[gvr] || sm.pinned[gvr] > 0 {
+		sm.accessMutex.Unlock()
+		return false
+	}
+	delete(sm.accessTracker, gvr)
+	sm.evicted[gvr] = size
+	sm.accessMutex.Unlock()
+
+	klog.Warningf("Informer for %s exceeds the memory budget %d bytes while syncing (%d bytes read), evicting it",
+		gvr.String(), sm.strategy.MemoryBudgetBytes, size)
+	sm.resourceCache.StopInformer(gvr)
+	return true
+}
+
+// liveOnly 检查资源是否因超出内存预算被驱逐，被驱逐的资源直接从API服务器读取；
+// 驱逐时的估算内存能放入剩余预算后，恢复使用Informer
+func (sm *StrategyManager) liveOnly(gvr schema.GroupVersionResource) (LiveLister, bool) {
+	sm.accessMutex.RLock()
+	size, evicted := sm.evicted[gvr]
+	sm.accessMutex.RUnlock()
+	if !evicted {
+		return nil, false
+	}
+
+	lister, ok := sm.resourceCache.(LiveLister)
+	reporter, reports := sm.resourceCache.(MemoryReporter)
+	if !ok || !reports {
+		return nil, false
+	}
+
+	if size <= sm.strategy.MemoryBudgetBytes-reporter.TotalMemoryBytes() {
+		sm.accessMutex.Lock()
+		delete(sm.evicted, gvr)
+		sm.accessMutex.Unlock()
+		klog.Infof("Memory budget has room for %s again, restarting its informer", gvr.String())
+		return nil, false
+	}
+	return lister, true
+}
+
+// preloadedSet 预加载资源的快速查找映射
+func (sm *StrategyManager) preloadedSet() map[schema.GroupVersionResource]bool {
+	preloaded := make(map[schema.GroupVersionResource]bool, len(sm.strategy.PreloadResources))
+	for _, gvr := range sm.strategy.PreloadResources {
+		preloaded[gvr] = true
+	}
+	return preloaded
+}
@@ -0,0 +1,80 @@
+package informer
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EventType Informer事件类型
+type EventType string
+
+const (
+	EventAdded   EventType = "Added"
+	EventUpdated EventType = "Updated"
+	EventDeleted EventType = "Deleted"
+)
+
+// EventListener 对象变化监听器，在Informer的事件处理协程中同步调用，不应阻塞
+type EventListener func(gvr schema.GroupVersionResource, eventType EventType, obj *unstructured.Unstructured)
+
+// StopListener Informer停止（被清理或因内存预算被驱逐）监听器，同步调用，不应阻塞
+type StopListener func(gvr schema.GroupVersionResource)
+
+// AddEventListener 注册所有资源的对象变化监听器
+// 监听器注册在管理器上而不是单个Informer上，Informer被清理后重新启动时仍然有效
+func (im *InformerManager) AddEventListener(listener EventListener) {
+	im.listenersMutex.Lock()
+	defer im.listenersMutex.Unlock()
+	im.listeners = append(im.listeners, listener)
+}
+
+// AddStopListener 注册所有资源的Informer停止监听器，停止后缓存中不再有该资源的对象
+func (im *InformerManager) AddStopListener(listener StopListener) {
+	im.listenersMutex.Lock()
+	defer im.listenersMutex.Unlock()
+	im.stopListeners = append(im.stopListeners, listener)
+}
+
+// notifyStop 通知所有停止监听器
+func (im *InformerManager) notifyStop(gvr schema.GroupVersionResource) {
+	im.listenersMutex.RLock()
+	listeners := im.stopListeners
+	im.listenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(gvr)
+	}
+}
+
+// isResync 检查更新事件是否来自定期resync或重新LIST，对象本身没有变化：
+// resync时新旧对象是同一个指针，重新LIST（如从快照恢复后）时resourceVersion相同
+func isResync(oldObj, newObj interface{}) bool {
+	if oldObj == newObj {
+		return true
+	}
+	oldU, oldOK := oldObj.(*unstructured.Unstructured)
+	newU, newOK := newObj.(*unstructured.Unstructured)
+	return oldOK && newOK && oldU.GetResourceVersion() != "" &&
+		oldU.GetResourceVersion() == newU.GetResourceVersion()
+}
+
+// notify 通知所有监听器
+func (im *InformerManager) notify(gvr schema.GroupVersionResource, eventType EventType, obj interface{}) {
+	// 删除事件可能收到DeletedFinalStateUnknown
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	im.listenersMutex.RLock()
+	listeners := im.listeners
+	im.listenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(gvr, eventType, u)
+	}
+}
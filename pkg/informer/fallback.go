@@ -0,0 +1,119 @@
+package informer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// Source 对象数据来源
+type Source string
+
+const (
+	// SourceCache 数据来自Informer缓存
+	SourceCache Source = "cache"
+	// SourceLive 数据来自对API服务器的直接分页LIST
+	SourceLive Source = "live"
+)
+
+// serverFieldSelectors API服务器支持作为fieldSelector的索引，直接LIST时交给服务器过滤，
+// 避免按节点读取Pod时每次都LIST整个集群
+var serverFieldSelectors = map[schema.GroupVersionResource]map[string]string{
+	podsGVR: {PodNodeNameIndex: "spec.nodeName"},
+}
+
+// liveFieldSelector 返回索引查询对应的服务器端fieldSelector
+func liveFieldSelector(gvr schema.GroupVersionResource, indexName, value string) string {
+	field, ok := serverFieldSelectors[gvr][indexName]
+	if !ok {
+		return ""
+	}
+	return fields.OneTermEqualSelector(field, value).String()
+}
+
+// fallbackLister 决定是否直接LIST：资源因内存预算被驱逐，或Informer已启动但尚未同步完成；
+// 不支持直接LIST的缓存（如离线转储）返回false，由调用方等待同步
+func (sm *StrategyManager) fallbackLister(gvr schema.GroupVersionResource, namespaced bool) (LiveLister, bool, error) {
+	if lister, live := sm.liveOnly(gvr); live {
+		return lister, true, nil
+	}
+
+	if err := sm.EnsureInformer(gvr, namespaced); err != nil {
+		return nil, false, err
+	}
+	if sm.resourceCache.IsReady(gvr) {
+		return nil, false, nil
+	}
+
+	lister, ok := sm.resourceCache.(LiveLister)
+	return lister, ok, nil
+}
+
+// listLive 直接分页LIST资源，相同资源、命名空间和fieldSelector的并发请求合并为一次LIST，
+// 结果在调用方之间共享，只能读取；所有调用方的ctx都取消（如客户端断开）后中止LIST。
+// 集群级资源忽略namespace
+func (sm *StrategyManager) listLive(ctx context.Context, lister LiveLister, gvr schema.GroupVersionResource, namespace string, namespaced bool, fieldSelector string) ([]*unstructured.Unstructured, error) {
+	if !namespaced || namespace == "all" {
+		namespace = ""
+	}
+
+	key := gvr.String() + "|" + namespace + "|" + fieldSelector
+	objects, shared, err := sm.liveGroup.Do(ctx, key, func(ctx context.Context) ([]*unstructured.Unstructured, error) {
+		klog.V(4).Infof("Listing %s in namespace %q with field selector %q from API server", gvr.String(), namespace, fieldSelector)
+		// 管理器关闭时同样中止
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(sm.ctx, cancel)
+		defer stop()
+		return lister.ListLive(ctx, gvr, namespace, fieldSelector)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		klog.V(5).Infof("Shared live list of %s in namespace %q with concurrent requests", gvr.String(), namespace)
+	}
+	return objects, nil
+}
+
+// filterByIndex 用资源声明的索引函数过滤直接LIST得到的对象，与ByIndex结果一致
+func filterByIndex(gvr schema.GroupVersionResource, objects []*unstructured.Unstructured, indexName, value string) ([]*unstructured.Unstructured, error) {
+	indexFunc, exists := DefaultIndexRegistry().Indexers(gvr)[indexName]
+	if !exists {
+		return nil, fmt.Errorf("index %s is not declared for %s", indexName, gvr.String())
+	}
+
+	result := make([]*unstructured.Unstructured, 0)
+	for _, obj := range objects {
+		values, err := indexFunc(obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if v == value {
+				result = append(result, obj)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// namespacesOf 收集对象所在的命名空间
+func namespacesOf(objects []*unstructured.Unstructured) []string {
+	seen := make(map[string]bool)
+	namespaces := make([]string, 0)
+	for _, obj := range objects {
+		if ns := obj.GetNamespace(); ns != "" && !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
@@ -0,0 +1,301 @@
+package informer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// IndexType 索引类型
+type IndexType string
+
+const (
+	// IndexLabel 按标签值建立索引
+	IndexLabel IndexType = "label"
+	// IndexOwnerUID 按ownerReferences中的UID建立索引
+	IndexOwnerUID IndexType = "ownerUID"
+	// IndexJSONPath 按JSONPath表达式的结果建立索引
+	IndexJSONPath IndexType = "jsonpath"
+)
+
+// PodNodeNameIndex Pod按spec.nodeName建立的索引名称
+const PodNodeNameIndex = "spec.nodeName"
+
+// OwnerUIDIndex 按所有者UID建立的索引名称
+const OwnerUIDIndex = "ownerUID"
+
+// podsGVR Pod资源
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// IndexSpec 资源的二级索引声明
+type IndexSpec struct {
+	Type IndexType `json:"type"`
+	// Label 标签键（label类型）
+	Label string `json:"label,omitempty"`
+	// JSONPath 字段路径，如spec.nodeName或{.spec.volumes[*].name}（jsonpath类型）
+	JSONPath string `json:"jsonPath,omitempty"`
+}
+
+// Name 返回索引名称：label:<key>、ownerUID或去掉花括号和前导点的JSONPath，
+// 后者与fieldSelector中的字段名一致
+func (spec IndexSpec) Name() string {
+	switch spec.Type {
+	case IndexLabel:
+		return "label:" + spec.Label
+	case IndexOwnerUID:
+		return OwnerUIDIndex
+	default:
+		return fieldPath(spec.JSONPath)
+	}
+}
+
+// indexFunc 生成索引函数
+func (spec IndexSpec) indexFunc() (cache.IndexFunc, error) {
+	switch spec.Type {
+	case IndexLabel:
+		if spec.Label == "" {
+			return nil, fmt.Errorf("label index requires a label key")
+		}
+		return func(obj interface{}) ([]string, error) {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object type %T", obj)
+			}
+			if value, ok := u.GetLabels()[spec.Label]; ok {
+				return []string{value}, nil
+			}
+			return nil, nil
+		}, nil
+	case IndexOwnerUID:
+		return func(obj interface{}) ([]string, error) {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object type %T", obj)
+			}
+			var uids []string
+			for _, owner := range u.GetOwnerReferences() {
+				uids = append(uids, string(owner.UID))
+			}
+			return uids, nil
+		}, nil
+	case IndexJSONPath:
+		extractor, err := newFieldExtractor(spec.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		return func(obj interface{}) ([]string, error) {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object type %T", obj)
+			}
+			return extractor.values(u), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown index type %q", spec.Type)
+}
+
+// ParseIndexSpec 解析索引声明：label:<key>、ownerUID或jsonpath:<path>
+func ParseIndexSpec(value string) (IndexSpec, error) {
+	kind, arg, _ := strings.Cut(value, ":")
+	switch IndexType(kind) {
+	case IndexLabel:
+		return IndexSpec{Type: IndexLabel, Label: arg}, nil
+	case IndexOwnerUID:
+		return IndexSpec{Type: IndexOwnerUID}, nil
+	case IndexJSONPath:
+		return IndexSpec{Type: IndexJSONPath, JSONPath: arg}, nil
+	}
+	return IndexSpec{}, fmt.Errorf("invalid index %q, expected label:<key>, ownerUID or jsonpath:<path>", value)
+}
+
+// ParseResourceIndex 解析命令行中的索引声明，格式为<resource>.<version>[.<group>]=<index>，
+// 如pods.v1=jsonpath:spec.serviceAccountName或deployments.v1.apps=label:app
+func ParseResourceIndex(value string) (schema.GroupVersionResource, IndexSpec, error) {
+	resourceArg, indexArg, ok := strings.Cut(value, "=")
+	if !ok {
+		return schema.GroupVersionResource{}, IndexSpec{}, fmt.Errorf("invalid index %q, expected <resource>.<version>[.<group>]=<index>", value)
+	}
+
+	gvr, err := ParseResource(resourceArg)
+	if err != nil {
+		return schema.GroupVersionResource{}, IndexSpec{}, err
+	}
+
+	spec, err := ParseIndexSpec(indexArg)
+	return gvr, spec, err
+}
+
+// ParseResource 解析<resource>.<version>[.<group>]格式的资源，如pods.v1、deployments.v1.apps
+func ParseResource(value string) (schema.GroupVersionResource, error) {
+	parts := strings.SplitN(value, ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q, expected <resource>.<version>[.<group>]", value)
+	}
+	gvr := schema.GroupVersionResource{Resource: parts[0], Version: parts[1]}
+	if len(parts) == 3 {
+		gvr.Group = parts[2]
+	}
+	return gvr, nil
+}
+
+// IndexRegistry 按GVR声明的Informer二级索引，在Informer启动时附加
+type IndexRegistry struct {
+	mutex sync.RWMutex
+	specs map[schema.GroupVersionResource][]IndexSpec
+}
+
+// NewIndexRegistry 创建索引注册表，包含内置的Pod spec.nodeName索引
+func NewIndexRegistry() *IndexRegistry {
+	r := &IndexRegistry{specs: make(map[schema.GroupVersionResource][]IndexSpec)}
+	r.specs[podsGVR] = []IndexSpec{{Type: IndexJSONPath, JSONPath: PodNodeNameIndex}}
+	return r
+}
+
+var defaultIndexRegistry = NewIndexRegistry()
+
+// DefaultIndexRegistry 返回进程共用的索引注册表
+func DefaultIndexRegistry() *IndexRegistry {
+	return defaultIndexRegistry
+}
+
+// Register 为资源声明索引，只对之后启动的Informer生效
+func (r *IndexRegistry) Register(gvr schema.GroupVersionResource, spec IndexSpec) error {
+	if _, err := spec.indexFunc(); err != nil {
+		return fmt.Errorf("invalid index for %s: %v", gvr.String(), err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, existing := range r.specs[gvr] {
+		if existing.Name() == spec.Name() {
+			return nil
+		}
+	}
+	r.specs[gvr] = append(r.specs[gvr], spec)
+	return nil
+}
+
+// Specs 返回资源声明的索引
+func (r *IndexRegistry) Specs(gvr schema.GroupVersionResource) []IndexSpec {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	specs := make([]IndexSpec, len(r.specs[gvr]))
+	copy(specs, r.specs[gvr])
+	return specs
+}
+
+// All 返回所有资源声明的索引，键为GVR字符串
+func (r *IndexRegistry) All() map[string][]IndexSpec {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	all := make(map[string][]IndexSpec, len(r.specs))
+	for gvr, specs := range r.specs {
+		all[gvr.String()] = append([]IndexSpec(nil), specs...)
+	}
+	return all
+}
+
+// Indexers 返回资源Informer需要附加的索引，总是包含命名空间索引
+func (r *IndexRegistry) Indexers(gvr schema.GroupVersionResource) cache.Indexers {
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	for _, spec := range r.Specs(gvr) {
+		// 注册时已校验
+		indexFunc, _ := spec.indexFunc()
+		indexers[spec.Name()] = indexFunc
+	}
+	return indexers
+}
+
+// Match 为选择器找到可用的索引，按字段、标签、所有者的顺序选择第一个精确匹配
+func (r *IndexRegistry) Match(gvr schema.GroupVersionResource, selector Selector) (string, string, bool) {
+	specs := r.Specs(gvr)
+	sort.SliceStable(specs, func(i, j int) bool { return indexPriority(specs[i].Type) < indexPriority(specs[j].Type) })
+
+	for _, spec := range specs {
+		switch spec.Type {
+		case IndexJSONPath:
+			if selector.Fields != nil {
+				// 字段缺失的对象不在索引中，空值需要扫描
+				if value, ok := selector.Fields.RequiresExactMatch(spec.Name()); ok && value != "" {
+					return spec.Name(), value, true
+				}
+			}
+		case IndexLabel:
+			if selector.Labels != nil {
+				if value, ok := selector.Labels.RequiresExactMatch(spec.Label); ok {
+					return spec.Name(), value, true
+				}
+			}
+		case IndexOwnerUID:
+			if selector.OwnerUID != "" {
+				return spec.Name(), selector.OwnerUID, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// indexPriority 字段索引通常选择性最高
+func indexPriority(indexType IndexType) int {
+	switch indexType {
+	case IndexJSONPath:
+		return 0
+	case IndexLabel:
+		return 1
+	}
+	return 2
+}
+
+// fieldPath 去掉JSONPath的花括号和前导点
+func fieldPath(path string) string {
+	path = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(path), "{"), "}")
+	return strings.TrimPrefix(path, ".")
+}
+
+// fieldExtractor 从对象中读取JSONPath的值
+// jsonpath.JSONPath求值时会修改自身状态，因此用互斥锁保护
+type fieldExtractor struct {
+	mutex sync.Mutex
+	path  *jsonpath.JSONPath
+}
+
+// newFieldExtractor 编译JSONPath，接受spec.nodeName或{.spec.nodeName}
+func newFieldExtractor(path string) (*fieldExtractor, error) {
+	if fieldPath(path) == "" {
+		return nil, fmt.Errorf("jsonpath index requires a path")
+	}
+	parser := jsonpath.New(path).AllowMissingKeys(true)
+	if err := parser.Parse("{." + fieldPath(path) + "}"); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %v", path, err)
+	}
+	return &fieldExtractor{path: parser}, nil
+}
+
+// values 返回JSONPath匹配到的所有标量值
+func (e *fieldExtractor) values(obj *unstructured.Unstructured) []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	results, err := e.path.FindResults(obj.Object)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, result := range results {
+		for _, value := range result {
+			switch v := value.Interface().(type) {
+			case map[string]interface{}, []interface{}, nil:
+				continue
+			default:
+				values = append(values, fmt.Sprint(v))
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,204 @@
+package informer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SyncModeWatchList 通过sendInitialEvents流式获取初始对象
+	SyncModeWatchList = "watchlist"
+	// SyncModeList 通过（分页）LIST获取初始对象
+	SyncModeList = "list"
+
+	// initialEventsEndAnnotation 服务器在初始事件发送完毕后的书签上设置的注解
+	initialEventsEndAnnotation = "k8s.io/initial-events-end"
+	// watchListProgressInterval 流式同步时每收到多少个对象更新一次进度
+	watchListProgressInterval = 1000
+	// watchListTimeoutSeconds 流式同步的最长时间，不识别sendInitialEvents的服务器不会发送结束书签，超时后回退为LIST
+	watchListTimeoutSeconds = 60
+)
+
+// SyncOptions Informer初始同步方式
+type SyncOptions struct {
+	// WatchList 使用WatchList（sendInitialEvents）流式获取初始对象，服务器不支持时回退为分页LIST
+	WatchList bool
+	// PageSize 分页LIST每页的对象数，0表示使用client-go默认行为（从watch缓存一次性LIST）
+	PageSize int64
+}
+
+// DefaultSyncOptions 默认同步方式：不使用WatchList，使用client-go默认的LIST
+func DefaultSyncOptions() SyncOptions {
+	return SyncOptions{}
+}
+
+// SetSyncOptions 设置Informer初始同步方式，需在启动Informer之前调用
+func (im *InformerManager) SetSyncOptions(options SyncOptions) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	im.syncOptions = options
+}
+
+// syncProgress 单个Informer一次初始同步（或重新LIST）的进度，只在reflector的goroutine中访问
+type syncProgress struct {
+	// 服务器不支持WatchList后不再尝试
+	watchListUnsupported bool
+	mode                 string
+	objects              int
+	pages                int
+	// bytes 本次同步已读取对象的估算内存
+	bytes int64
+}
+
+// listObjects 获取资源的完整列表，优先使用WatchList，失败时回退为分页LIST
+// options来自reflector的pager，带continue时表示正在读取LIST的后续页
+func (im *InformerManager) listObjects(ctx context.Context, gvr schema.GroupVersionResource, client resourceClient, options metav1.ListOptions, progress *syncProgress) (*unstructured.UnstructuredList, error) {
+	if options.Continue == "" && im.syncOptions.WatchList && !progress.watchListUnsupported {
+		list, err := im.watchList(ctx, gvr, client, options, progress)
+		if err == nil {
+			return list, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, errMemoryBudgetExceeded) {
+			return nil, err
+		}
+		if isWatchListUnsupported(err) {
+			progress.watchListUnsupported = true
+		}
+		klog.Warningf("Watch-list for %s failed, falling back to paginated LIST: %v", gvr.String(), err)
+	}
+
+	// pager的Limit为0表示reflector有意从watch缓存一次性LIST（如按resourceVersion重新LIST），保持不变
+	if im.syncOptions.PageSize > 0 && options.Limit > 0 {
+		options.Limit = im.syncOptions.PageSize
+		// resourceVersion=0由watch缓存处理且会忽略limit，改为从etcd分页读取
+		if options.ResourceVersion == "0" {
+			options.ResourceVersion = ""
+		}
+	}
+
+	if options.Continue == "" {
+		progress.mode = SyncModeList
+		progress.objects = 0
+		progress.pages = 0
+		progress.bytes = 0
+	}
+	list, err := client.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	progress.objects += len(list.Items)
+	progress.pages++
+	for i := range list.Items {
+		progress.bytes += EstimateObjectSize(&list.Items[i])
+	}
+	im.updateSyncProgress(gvr, progress)
+	if err := im.checkSyncMemory(gvr, progress.bytes); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// watchList 通过sendInitialEvents=true的watch流式获取初始对象，收到初始事件结束书签后组装为列表
+// 服务器无需在内存中构建完整的LIST响应；返回的列表交给reflector后，reflector从书签的resourceVersion开始watch
+func (im *InformerManager) watchList(ctx context.Context, gvr schema.GroupVersionResource, client resourceClient, options metav1.ListOptions, progress *syncProgress) (*unstructured.UnstructuredList, error) {
+	sendInitialEvents := true
+	timeoutSeconds := int64(watchListTimeoutSeconds)
+	w, err := client.Watch(ctx, metav1.ListOptions{
+		LabelSelector:        options.LabelSelector,
+		FieldSelector:        options.FieldSelector,
+		ResourceVersion:      options.ResourceVersion,
+		ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+		SendInitialEvents:    &sendInitialEvents,
+		AllowWatchBookmarks:  true,
+		TimeoutSeconds:       &timeoutSeconds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	progress.mode = SyncModeWatchList
+	progress.objects = 0
+	progress.pages = 0
+	progress.bytes = 0
+	im.updateSyncProgress(gvr, progress)
+
+	store := cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watch-list stream for %s closed before initial events ended", gvr.String())
+			}
+			if event.Type == watch.Error {
+				return nil, apierrors.FromObject(event.Object)
+			}
+
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object type %T in watch-list stream for %s", event.Object, gvr.String())
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if err := store.Update(obj); err != nil {
+					return nil, err
+				}
+				progress.objects++
+				progress.bytes += EstimateObjectSize(obj)
+				if progress.objects%watchListProgressInterval == 0 {
+					im.updateSyncProgress(gvr, progress)
+					if err := im.checkSyncMemory(gvr, progress.bytes); err != nil {
+						return nil, err
+					}
+				}
+			case watch.Deleted:
+				if err := store.Delete(obj); err != nil {
+					return nil, err
+				}
+			case watch.Bookmark:
+				if obj.GetAnnotations()[initialEventsEndAnnotation] != "true" {
+					continue
+				}
+				items := store.List()
+				list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, len(items))}
+				list.SetResourceVersion(obj.GetResourceVersion())
+				for _, item := range items {
+					list.Items = append(list.Items, *item.(*unstructured.Unstructured))
+				}
+				progress.objects = len(list.Items)
+				im.updateSyncProgress(gvr, progress)
+				return list, nil
+			}
+		}
+	}
+}
+
+// isWatchListUnsupported 检查错误是否表示服务器不支持WatchList（功能门未开启或版本过旧）
+func isWatchListUnsupported(err error) bool {
+	return apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err) || apierrors.IsNotAcceptable(err)
+}
+
+// updateSyncProgress 将同步进度写入ResourceStat
+func (im *InformerManager) updateSyncProgress(gvr schema.GroupVersionResource, progress *syncProgress) {
+	im.statsMutex.Lock()
+	defer im.statsMutex.Unlock()
+
+	stat := im.stats.ResourceStats[gvr.String()]
+	stat.SyncMode = progress.mode
+	stat.SyncedObjects = progress.objects
+	stat.SyncPages = progress.pages
+	im.stats.ResourceStats[gvr.String()] = stat
+}
@@ -0,0 +1,48 @@
+package informer
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultLivePageSize 未配置分页大小时直接LIST的每页对象数
+const defaultLivePageSize = 500
+
+// LiveLister 直接从API服务器分页读取对象，不经过Informer缓存
+type LiveLister interface {
+	ListLive(ctx context.Context, gvr schema.GroupVersionResource, namespace, fieldSelector string) ([]*unstructured.Unstructured, error)
+}
+
+// ListLive 通过dynamic客户端分页LIST资源，namespace为空或"all"时读取所有命名空间，
+// fieldSelector不为空时由API服务器过滤
+func (im *InformerManager) ListLive(ctx context.Context, gvr schema.GroupVersionResource, namespace, fieldSelector string) ([]*unstructured.Unstructured, error) {
+	pageSize := im.syncOptions.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultLivePageSize
+	}
+
+	var client dynamic.ResourceInterface = im.dynamicClient.Resource(gvr)
+	if namespace != "" && namespace != "all" {
+		client = im.dynamicClient.Resource(gvr).Namespace(namespace)
+	}
+
+	var objects []*unstructured.Unstructured
+	options := metav1.ListOptions{Limit: pageSize, FieldSelector: fieldSelector}
+	for {
+		list, err := client.List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+		if list.GetContinue() == "" {
+			return objects, nil
+		}
+		options.Continue = list.GetContinue()
+	}
+}
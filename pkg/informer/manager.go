@@ -7,10 +7,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
@@ -18,7 +21,10 @@ import (
 // ResourceCache 资源缓存接口
 type ResourceCache interface {
 	// GetObjects 获取指定资源的所有对象
+	// 返回缓存中的共享对象，调用方只能读取（如直接序列化），修改前必须DeepCopy
 	GetObjects(gvr schema.GroupVersionResource, namespace string) ([]*unstructured.Unstructured, error)
+	// GetObjectsByIndex 通过索引获取对象，索引名称见IndexSpec.Name
+	GetObjectsByIndex(gvr schema.GroupVersionResource, indexName, value string) ([]*unstructured.Unstructured, error)
 	// GetNamespaces 获取指定资源的所有命名空间
 	GetNamespaces(gvr schema.GroupVersionResource) ([]string, error)
 	// IsReady 检查指定资源的Informer是否已就绪
@@ -35,6 +41,7 @@ type ResourceCache interface {
 type CacheStats struct {
 	ActiveInformers int                     `json:"activeInformers"`
 	TotalObjects    int                     `json:"totalObjects"`
+	MemoryBytes     int64                   `json:"memoryBytes"` // 所有Informer缓存的估算内存
 	ResourceStats   map[string]ResourceStat `json:"resourceStats"`
 	LastUpdate      time.Time               `json:"lastUpdate"`
 	SyncStatus      map[string]bool         `json:"syncStatus"`
@@ -47,25 +54,53 @@ type ResourceStat struct {
 	LastSync       time.Time     `json:"lastSync"`
 	SyncDuration   time.Duration `json:"syncDuration"`
 	IsReady        bool          `json:"isReady"`
+	Stale          bool          `json:"stale"`         // 快照数据尚未与API服务器重新同步
+	FromSnapshot   bool          `json:"fromSnapshot"`  // 启动时从快照恢复
+	Protobuf       bool          `json:"protobuf"`      // 使用protobuf编码的list/watch
+	SyncMode       string        `json:"syncMode"`      // 最近一次完整同步的方式：watchlist或list
+	SyncedObjects  int           `json:"syncedObjects"` // 最近一次完整同步已收到的对象数
+	SyncPages      int           `json:"syncPages"`     // 分页LIST已读取的页数
+	MemoryBytes    int64         `json:"memoryBytes"`   // Informer缓存的估算内存
+	Evicted        bool          `json:"evicted"`       // 超出内存预算被驱逐，请求直接分页LIST
 }
 
 // InformerManager Informer管理器
 type InformerManager struct {
-	dynamicClient   dynamic.Interface
-	informerFactory dynamicinformer.DynamicSharedInformerFactory
-	informers       map[schema.GroupVersionResource]cache.SharedIndexInformer
-	stopChannels    map[schema.GroupVersionResource]chan struct{}
-	mutex           sync.RWMutex
-	ctx             context.Context
-	cancel          context.CancelFunc
-	stats           CacheStats
-	statsMutex      sync.RWMutex
+	dynamicClient dynamic.Interface
+	informers     map[schema.GroupVersionResource]cache.SharedIndexInformer
+	stopChannels  map[schema.GroupVersionResource]chan struct{}
+	mutex         sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	stats         CacheStats
+	statsMutex    sync.RWMutex
 
 	// 性能优化相关
-	objectPool    sync.Pool
 	readyStatus   map[schema.GroupVersionResource]*atomic.Bool
+	memoryBytes   map[schema.GroupVersionResource]*atomic.Int64
 	readyMutex    sync.RWMutex
 	syncWaitGroup sync.WaitGroup
+
+	// 快照相关
+	snapshotStore SnapshotStore
+	staleStatus   map[schema.GroupVersionResource]*atomic.Bool
+
+	// protobuf相关
+	protobufConfig    *rest.Config
+	protobufResources map[schema.GroupVersionResource]bool
+	protobufAll       bool
+
+	// 初始同步方式
+	syncOptions SyncOptions
+
+	// 内存预算，同步过程中超出时通过onBudgetExceeded驱逐，0表示不限制
+	memoryBudget     int64
+	onBudgetExceeded func(gvr schema.GroupVersionResource, size int64) bool
+
+	// 对象变化监听器
+	listeners      []EventListener
+	stopListeners  []StopListener
+	listenersMutex sync.RWMutex
 }
 
 // NewInformerManager 创建新的Informer管理器
@@ -73,23 +108,20 @@ func NewInformerManager(dynamicClient dynamic.Interface) *InformerManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &InformerManager{
-		dynamicClient:   dynamicClient,
-		informerFactory: dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 30*time.Second),
-		informers:       make(map[schema.GroupVersionResource]cache.SharedIndexInformer),
-		stopChannels:    make(map[schema.GroupVersionResource]chan struct{}),
-		readyStatus:     make(map[schema.GroupVersionResource]*atomic.Bool),
-		ctx:             ctx,
-		cancel:          cancel,
+		dynamicClient: dynamicClient,
+		informers:     make(map[schema.GroupVersionResource]cache.SharedIndexInformer),
+		stopChannels:  make(map[schema.GroupVersionResource]chan struct{}),
+		readyStatus:   make(map[schema.GroupVersionResource]*atomic.Bool),
+		memoryBytes:   make(map[schema.GroupVersionResource]*atomic.Int64),
+		staleStatus:   make(map[schema.GroupVersionResource]*atomic.Bool),
+		syncOptions:   DefaultSyncOptions(),
+		ctx:           ctx,
+		cancel:        cancel,
 		stats: CacheStats{
 			ResourceStats: make(map[string]ResourceStat),
 			SyncStatus:    make(map[string]bool),
 			LastUpdate:    time.Now(),
 		},
-		objectPool: sync.Pool{
-			New: func() interface{} {
-				return make([]*unstructured.Unstructured, 0, 100)
-			},
-		},
 	}
 }
 
@@ -106,39 +138,61 @@ func (im *InformerManager) StartInformer(gvr schema.GroupVersionResource, namesp
 
 	klog.Infof("Starting informer for resource: %s", gvr.String())
 
+	// 读取快照，存在时Informer直接从快照的resourceVersion开始watch
+	restored := im.loadSnapshot(gvr)
+
+	// 创建停止通道，Informer的list/watch请求随其一起取消
+	stopCh := make(chan struct{})
+
 	// 创建Informer
-	var informer cache.SharedIndexInformer
-	if namespaced {
-		informer = im.informerFactory.ForResource(gvr).Informer()
-	} else {
-		informer = im.informerFactory.ForResource(gvr).Informer()
-	}
+	informer := im.newInformer(im.informerContext(stopCh), gvr, restored)
 
 	// 初始化就绪状态
 	readyFlag := &atomic.Bool{}
 	readyFlag.Store(false)
+	staleFlag := &atomic.Bool{}
+	staleFlag.Store(restored != nil)
+	memorySize := &atomic.Int64{}
 	im.readyMutex.Lock()
 	im.readyStatus[gvr] = readyFlag
+	im.staleStatus[gvr] = staleFlag
+	im.memoryBytes[gvr] = memorySize
 	im.readyMutex.Unlock()
 
+	if restored != nil {
+		im.statsMutex.Lock()
+		stat := im.stats.ResourceStats[gvr.String()]
+		stat.Stale = true
+		stat.FromSnapshot = true
+		im.stats.ResourceStats[gvr.String()] = stat
+		im.statsMutex.Unlock()
+	}
+
 	// 添加事件处理器
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			im.updateStats(gvr, "add")
+			im.notify(gvr, EventAdded, obj)
 			klog.V(6).Infof("Added object for %s", gvr.String())
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
+			if isResync(oldObj, newObj) {
+				return
+			}
 			im.updateStats(gvr, "update")
+			im.notify(gvr, EventUpdated, newObj)
 			klog.V(6).Infof("Updated object for %s", gvr.String())
 		},
 		DeleteFunc: func(obj interface{}) {
 			im.updateStats(gvr, "delete")
+			im.notify(gvr, EventDeleted, obj)
 			klog.V(6).Infof("Deleted object for %s", gvr.String())
 		},
 	})
 
-	// 创建停止通道
-	stopCh := make(chan struct{})
+	// 维护估算内存
+	informer.AddEventHandler(memoryHandler(memorySize))
+
 	im.stopChannels[gvr] = stopCh
 	im.informers[gvr] = informer
 
@@ -186,6 +240,91 @@ func (im *InformerManager) StartInformer(gvr schema.GroupVersionResource, namesp
 	return nil
 }
 
+// resourceClient Informer使用的list/watch客户端，由dynamic客户端或protobufClient实现
+type resourceClient interface {
+	List(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error)
+	Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error)
+}
+
+// resourceClient 选择资源的list/watch客户端，protobuf客户端创建失败时回退为JSON
+func (im *InformerManager) resourceClient(gvr schema.GroupVersionResource) (resourceClient, bool) {
+	if im.useProtobuf(gvr) {
+		client, err := im.newProtobufClient(gvr)
+		if err == nil {
+			return client, true
+		}
+		klog.Warningf("Falling back to JSON for %s: %v", gvr.String(), err)
+	}
+	return im.dynamicClient.Resource(gvr), false
+}
+
+// informerContext 派生在Informer停止或管理器停止时取消的上下文，
+// StopInformer因此能中止进行中的WatchList和分页LIST，而不必等它们读完
+func (im *InformerManager) informerContext(stopCh <-chan struct{}) context.Context {
+	ctx, cancel := context.WithCancel(im.ctx)
+	go func() {
+		defer cancel()
+		select {
+		case <-stopCh:
+		case <-ctx.Done():
+		}
+	}()
+	return ctx
+}
+
+// newInformer 为指定资源创建独立的Informer，list/watch请求使用ctx
+// restored不为空时，首次LIST直接返回快照内容，随后从快照的resourceVersion恢复watch；
+// 若该版本已过期，reflector会自动回退为完整LIST
+func (im *InformerManager) newInformer(ctx context.Context, gvr schema.GroupVersionResource, restored *unstructured.UnstructuredList) cache.SharedIndexInformer {
+	client, protobuf := im.resourceClient(gvr)
+	if protobuf {
+		klog.Infof("Using protobuf list/watch for %s", gvr.String())
+		im.statsMutex.Lock()
+		stat := im.stats.ResourceStats[gvr.String()]
+		stat.Protobuf = true
+		im.stats.ResourceStats[gvr.String()] = stat
+		im.statsMutex.Unlock()
+	}
+	restoredVersion := ""
+	if restored != nil {
+		restoredVersion = restored.GetResourceVersion()
+	}
+	progress := &syncProgress{}
+
+	return cache.NewSharedIndexInformerWithOptions(
+		&cache.ListWatch{
+			// ListFunc和WatchFunc只在reflector的单个goroutine中调用
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if restored != nil {
+					list := restored
+					restored = nil
+					return list, nil
+				}
+
+				list, err := im.listObjects(ctx, gvr, client, options, progress)
+				if err == nil {
+					im.markFresh(gvr)
+				}
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				w, err := client.Watch(ctx, options)
+				if err == nil && restoredVersion != "" && options.ResourceVersion == restoredVersion {
+					// watch从快照版本成功恢复，后续事件会补齐快照之后的变化
+					im.markFresh(gvr)
+				}
+				return w, err
+			},
+		},
+		&unstructured.Unstructured{},
+		cache.SharedIndexInformerOptions{
+			ResyncPeriod:      30 * time.Second,
+			Indexers:          DefaultIndexRegistry().Indexers(gvr),
+			ObjectDescription: gvr.String(),
+		},
+	)
+}
+
 // StopInformer 停止指定资源的Informer
 func (im *InformerManager) StopInformer(gvr schema.GroupVersionResource) {
 	im.mutex.Lock()
@@ -199,13 +338,24 @@ func (im *InformerManager) StopInformer(gvr schema.GroupVersionResource) {
 		// 清理就绪状态
 		im.readyMutex.Lock()
 		delete(im.readyStatus, gvr)
+		delete(im.staleStatus, gvr)
+		delete(im.memoryBytes, gvr)
 		im.readyMutex.Unlock()
 
+		im.statsMutex.Lock()
+		if stat, exists := im.stats.ResourceStats[gvr.String()]; exists {
+			stat.MemoryBytes = 0
+			im.stats.ResourceStats[gvr.String()] = stat
+		}
+		im.statsMutex.Unlock()
+
 		klog.Infof("Stopped informer for %s", gvr.String())
+		im.notifyStop(gvr)
 	}
 }
 
-// GetObjects 获取指定资源的所有对象（优化版本）
+// GetObjects 获取指定资源的所有对象
+// Informer收到变化时整体替换存储中的对象而不会原地修改，因此直接返回缓存对象，不做深拷贝
 func (im *InformerManager) GetObjects(gvr schema.GroupVersionResource, namespace string) ([]*unstructured.Unstructured, error) {
 	im.mutex.RLock()
 	informer, exists := im.informers[gvr]
@@ -219,43 +369,57 @@ func (im *InformerManager) GetObjects(gvr schema.GroupVersionResource, namespace
 		return nil, fmt.Errorf("informer for %s not synced yet", gvr.String())
 	}
 
-	store := informer.GetStore()
-	objects := store.List()
-
-	// 从对象池获取切片
-	result := im.objectPool.Get().([]*unstructured.Unstructured)
-	result = result[:0] // 重置长度但保留容量
-
-	defer func() {
-		// 归还到对象池
-		if cap(result) <= 1000 { // 避免池中对象过大
-			im.objectPool.Put(result)
+	// 指定命名空间时通过命名空间索引读取，不扫描整个缓存
+	var objects []interface{}
+	if namespace != "" && namespace != "all" {
+		var err error
+		objects, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query namespace index of %s: %v", gvr.String(), err)
 		}
-	}()
+	} else {
+		objects = informer.GetStore().List()
+	}
 
+	result := make([]*unstructured.Unstructured, 0, len(objects))
 	for _, obj := range objects {
-		unstructuredObj, ok := obj.(*unstructured.Unstructured)
-		if !ok {
-			continue
+		if unstructuredObj, ok := obj.(*unstructured.Unstructured); ok {
+			result = append(result, unstructuredObj)
 		}
+	}
 
-		// 如果指定了命名空间，进行过滤
-		if namespace != "" && namespace != "all" {
-			if unstructuredObj.GetNamespace() != namespace {
-				continue
-			}
-		}
+	klog.V(4).Infof("Retrieved %d objects for %s (namespace: %s)", len(result), gvr.String(), namespace)
+	return result, nil
+}
 
-		// 优化：只在需要时进行深拷贝
-		result = append(result, unstructuredObj.DeepCopy())
+// GetObjectsByIndex 通过Informer索引获取对象，不扫描整个缓存，与GetObjects一样返回共享对象
+func (im *InformerManager) GetObjectsByIndex(gvr schema.GroupVersionResource, indexName, value string) ([]*unstructured.Unstructured, error) {
+	im.mutex.RLock()
+	informer, exists := im.informers[gvr]
+	im.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("informer for %s not found", gvr.String())
 	}
 
-	// 创建新的切片返回，避免池对象被外部修改
-	finalResult := make([]*unstructured.Unstructured, len(result))
-	copy(finalResult, result)
+	if !im.IsReady(gvr) {
+		return nil, fmt.Errorf("informer for %s not synced yet", gvr.String())
+	}
+
+	objects, err := informer.GetIndexer().ByIndex(indexName, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index %s of %s: %v", indexName, gvr.String(), err)
+	}
 
-	klog.V(4).Infof("Retrieved %d objects for %s (namespace: %s)", len(finalResult), gvr.String(), namespace)
-	return finalResult, nil
+	result := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if unstructuredObj, ok := obj.(*unstructured.Unstructured); ok {
+			result = append(result, unstructuredObj)
+		}
+	}
+
+	klog.V(4).Infof("Retrieved %d objects for %s by index %s=%s", len(result), gvr.String(), indexName, value)
+	return result, nil
 }
 
 // GetNamespaces 获取指定资源的所有命名空间
@@ -306,38 +470,62 @@ func (im *InformerManager) IsReady(gvr schema.GroupVersionResource) bool {
 }
 
 // GetStats 获取缓存统计信息
+// 先在Informer锁内收集各资源的状态，再在统计锁内更新，两把锁不嵌套；返回的映射是副本
 func (im *InformerManager) GetStats() CacheStats {
-	im.statsMutex.RLock()
-	defer im.statsMutex.RUnlock()
+	type informerState struct {
+		ready   bool
+		memory  int64
+		objects int
+	}
 
-	// 更新活跃Informer数量和总对象数
 	im.mutex.RLock()
-	activeInformers := len(im.informers)
-	totalObjects := 0
-
+	states := make(map[string]informerState, len(im.informers))
 	for gvr, informer := range im.informers {
-		isReady := im.IsReady(gvr)
-		im.stats.SyncStatus[gvr.String()] = isReady
+		// 同步过程中内存持续增长，未就绪时也需要报告
+		state := informerState{ready: im.IsReady(gvr), memory: im.MemoryBytes(gvr)}
+		if state.ready {
+			state.objects = len(informer.GetStore().ListKeys())
+		}
+		states[gvr.String()] = state
+	}
+	im.mutex.RUnlock()
 
-		if isReady {
-			objectCount := len(informer.GetStore().List())
-			totalObjects += objectCount
+	im.statsMutex.Lock()
+	defer im.statsMutex.Unlock()
 
-			// 更新资源统计
-			if stat, exists := im.stats.ResourceStats[gvr.String()]; exists {
-				stat.ObjectCount = objectCount
+	totalObjects := 0
+	var totalMemory int64
+	for key, state := range states {
+		im.stats.SyncStatus[key] = state.ready
+		totalMemory += state.memory
+		totalObjects += state.objects
+
+		if stat, exists := im.stats.ResourceStats[key]; exists {
+			stat.MemoryBytes = state.memory
+			if state.ready {
+				// 更新资源统计
+				stat.ObjectCount = state.objects
 				stat.IsReady = true
-				im.stats.ResourceStats[gvr.String()] = stat
 			}
+			im.stats.ResourceStats[key] = stat
 		}
 	}
-	im.mutex.RUnlock()
 
-	im.stats.ActiveInformers = activeInformers
+	im.stats.ActiveInformers = len(states)
 	im.stats.TotalObjects = totalObjects
+	im.stats.MemoryBytes = totalMemory
 	im.stats.LastUpdate = time.Now()
 
-	return im.stats
+	stats := im.stats
+	stats.ResourceStats = make(map[string]ResourceStat, len(im.stats.ResourceStats))
+	for key, stat := range im.stats.ResourceStats {
+		stats.ResourceStats[key] = stat
+	}
+	stats.SyncStatus = make(map[string]bool, len(im.stats.SyncStatus))
+	for key, synced := range im.stats.SyncStatus {
+		stats.SyncStatus[key] = synced
+	}
+	return stats
 }
 
 // updateStats 更新统计信息
@@ -387,6 +575,9 @@ func (im *InformerManager) WaitForInitialSync(timeout time.Duration) error {
 func (im *InformerManager) Shutdown() {
 	klog.Info("Shutting down informer manager")
 
+	// 关闭前保存最新快照
+	im.SaveSnapshots()
+
 	im.mutex.Lock()
 	defer im.mutex.Unlock()
 
@@ -403,18 +594,14 @@ func (im *InformerManager) Shutdown() {
 	// 清理就绪状态
 	im.readyMutex.Lock()
 	im.readyStatus = make(map[schema.GroupVersionResource]*atomic.Bool)
+	im.staleStatus = make(map[schema.GroupVersionResource]*atomic.Bool)
+	im.memoryBytes = make(map[schema.GroupVersionResource]*atomic.Int64)
 	im.readyMutex.Unlock()
 
 	// 取消上下文
 	im.cancel()
 }
 
-// StartAll 启动所有已注册的Informer
-func (im *InformerManager) StartAll() {
-	klog.Info("Starting all informers")
-	im.informerFactory.Start(im.ctx.Done())
-}
-
 // WaitForCacheSync 等待所有缓存同步
 func (im *InformerManager) WaitForCacheSync() bool {
 	im.mutex.RLock()
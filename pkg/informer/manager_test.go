@@ -0,0 +1,341 @@
+package informer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+var (
+	benchObjects    = flag.String("bench-objects", "1000,10000", "Comma-separated store sizes for the informer benchmarks")
+	benchNamespaces = flag.Int("bench-namespaces", 50, "Number of namespaces the benchmark objects are spread across")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// 基准测试会反复启停Informer，屏蔽日志避免干扰输出
+	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
+	klog.InitFlags(klogFlags)
+	klogFlags.Set("logtostderr", "false")
+	klogFlags.Set("stderrthreshold", "FATAL")
+	klog.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+// BenchmarkGetObjects 零拷贝读取全部对象
+func BenchmarkGetObjects(b *testing.B) {
+	benchmarkReadPath(b, func(b *testing.B, manager *InformerManager) {
+		if _, err := manager.GetObjects(podsGVR, ""); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+// BenchmarkGetObjectsDeepCopy 逐个深拷贝的旧实现，作为GetObjects的对照
+func BenchmarkGetObjectsDeepCopy(b *testing.B) {
+	benchmarkReadPath(b, func(b *testing.B, manager *InformerManager) {
+		objects, err := manager.GetObjects(podsGVR, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		copies := make([]*unstructured.Unstructured, 0, len(objects))
+		for _, obj := range objects {
+			copies = append(copies, obj.DeepCopy())
+		}
+	})
+}
+
+// BenchmarkGetObjectsNamespace 通过命名空间索引读取
+func BenchmarkGetObjectsNamespace(b *testing.B) {
+	benchmarkReadPath(b, func(b *testing.B, manager *InformerManager) {
+		if _, err := manager.GetObjects(podsGVR, "ns-0"); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+// BenchmarkGetObjectsEncode 读取并逐个序列化为JSON
+func BenchmarkGetObjectsEncode(b *testing.B) {
+	benchmarkReadPath(b, func(b *testing.B, manager *InformerManager) {
+		objects, err := manager.GetObjects(podsGVR, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		encoder := json.NewEncoder(io.Discard)
+		for _, obj := range objects {
+			if err := encoder.Encode(obj.Object); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkInitialSync 对比JSON与protobuf编码下Informer初始同步的耗时和内存分配，
+// 并报告列表响应大小（list-B）和同步后缓存常驻的堆内存（retained-B）
+func BenchmarkInitialSync(b *testing.B) {
+	for _, count := range benchmarkCounts(b) {
+		server, bodies, err := fakeAPIServer(count, *benchNamespaces)
+		if err != nil {
+			b.Fatal(err)
+		}
+		config := &rest.Config{Host: server.URL, QPS: -1}
+		client, err := dynamic.NewForConfig(config)
+		if err != nil {
+			server.Close()
+			b.Fatal(err)
+		}
+
+		for _, encoding := range []string{"json", "protobuf"} {
+			protobuf := encoding == "protobuf"
+			mediaType := apiruntime.ContentTypeJSON
+			if protobuf {
+				mediaType = apiruntime.ContentTypeProtobuf
+			}
+
+			b.Run(fmt.Sprintf("%s/objects=%d", encoding, count), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					manager, err := syncPods(client, config, count, protobuf)
+					if err != nil {
+						b.Fatal(err)
+					}
+					manager.Shutdown()
+				}
+				b.StopTimer()
+
+				retained, err := retainedBytes(func() (*InformerManager, error) {
+					return syncPods(client, config, count, protobuf)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(float64(len(bodies[mediaType])), "list-B")
+				b.ReportMetric(float64(retained), "retained-B")
+			})
+		}
+		server.Close()
+	}
+}
+
+// benchmarkReadPath 对每个对象数量填充缓存，在子基准中运行读取场景
+func benchmarkReadPath(b *testing.B, read func(b *testing.B, manager *InformerManager)) {
+	for _, count := range benchmarkCounts(b) {
+		manager, err := newPopulatedManager(count, *benchNamespaces)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("objects=%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				read(b, manager)
+			}
+		})
+		manager.Shutdown()
+	}
+}
+
+// benchmarkCounts 解析-bench-objects
+func benchmarkCounts(b *testing.B) []int {
+	counts := make([]int, 0)
+	for _, value := range strings.Split(*benchObjects, ",") {
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || count <= 0 {
+			b.Fatalf("invalid object count %q", value)
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// newPopulatedManager 创建包含count个Pod的InformerManager并等待同步
+func newPopulatedManager(count, namespaces int) (*InformerManager, error) {
+	objects := make([]apiruntime.Object, 0, count)
+	for i := 0; i < count; i++ {
+		objects = append(objects, newPod(i, namespaces))
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(apiruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{podsGVR: "PodList"}, objects...)
+	manager := NewInformerManager(client)
+	if err := manager.StartInformer(podsGVR, true); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(2 * time.Minute)
+	for !manager.IsReady(podsGVR) {
+		if time.Now().After(deadline) {
+			manager.Shutdown()
+			return nil, fmt.Errorf("timeout waiting for %d objects to sync", count)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return manager, nil
+}
+
+// fakeAPIServer 只提供Pod的list和watch，按Accept头返回JSON或protobuf
+// list返回预先编码好的响应（同时返回给调用方用于比较大小），watch保持连接直到客户端断开
+func fakeAPIServer(count, namespaces int) (*httptest.Server, map[string][]byte, error) {
+	list := &corev1.PodList{}
+	list.APIVersion = "v1"
+	list.Kind = "PodList"
+	list.ResourceVersion = fmt.Sprintf("%d", count+1)
+	list.Items = make([]corev1.Pod, 0, count)
+	for i := 0; i < count; i++ {
+		var pod corev1.Pod
+		if err := apiruntime.DefaultUnstructuredConverter.FromUnstructured(newPod(i, namespaces).Object, &pod); err != nil {
+			return nil, nil, err
+		}
+		list.Items = append(list.Items, pod)
+	}
+
+	bodies := make(map[string][]byte)
+	for _, mediaType := range []string{apiruntime.ContentTypeJSON, apiruntime.ContentTypeProtobuf} {
+		info, ok := apiruntime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), mediaType)
+		if !ok {
+			return nil, nil, fmt.Errorf("no serializer for %s", mediaType)
+		}
+		body, err := apiruntime.Encode(scheme.Codecs.EncoderForVersion(info.Serializer, corev1.SchemeGroupVersion), list)
+		if err != nil {
+			return nil, nil, err
+		}
+		bodies[mediaType] = body
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		mediaType := apiruntime.ContentTypeJSON
+		if strings.HasPrefix(r.Header.Get("Accept"), apiruntime.ContentTypeProtobuf) {
+			mediaType = apiruntime.ContentTypeProtobuf
+		}
+
+		if r.URL.Query().Get("watch") == "true" {
+			if mediaType == apiruntime.ContentTypeProtobuf {
+				w.Header().Set("Content-Type", mediaType+";stream=watch")
+			} else {
+				w.Header().Set("Content-Type", mediaType)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+
+		w.Header().Set("Content-Type", mediaType)
+		w.Write(bodies[mediaType])
+	}
+	return httptest.NewServer(http.HandlerFunc(handler)), bodies, nil
+}
+
+// syncPods 创建InformerManager并等待所有Pod进入缓存
+func syncPods(client dynamic.Interface, config *rest.Config, count int, protobuf bool) (*InformerManager, error) {
+	manager := NewInformerManager(client)
+	if protobuf {
+		if err := manager.EnableProtobuf(config, []schema.GroupVersionResource{podsGVR}, false); err != nil {
+			return nil, err
+		}
+	}
+
+	var added atomic.Int64
+	done := make(chan struct{})
+	manager.AddEventListener(func(gvr schema.GroupVersionResource, eventType EventType, obj *unstructured.Unstructured) {
+		if eventType == EventAdded && added.Add(1) == int64(count) {
+			close(done)
+		}
+	})
+	if err := manager.StartInformer(podsGVR, true); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-done:
+		return manager, nil
+	case <-time.After(2 * time.Minute):
+		manager.Shutdown()
+		return nil, fmt.Errorf("timeout waiting for %d objects to sync", count)
+	}
+}
+
+// retainedBytes 测量同步完成后缓存常驻的堆内存
+func retainedBytes(sync func() (*InformerManager, error)) (int64, error) {
+	var before, after runtime.MemStats
+	// 等待之前停止的Informer协程退出，释放其缓存
+	time.Sleep(time.Second)
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	manager, err := sync()
+	if err != nil {
+		return 0, err
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	manager.Shutdown()
+
+	return int64(after.HeapAlloc) - int64(before.HeapAlloc), nil
+}
+
+// newPod 生成一个大小接近真实Pod的对象
+func newPod(i, namespaces int) *unstructured.Unstructured {
+	name := fmt.Sprintf("pod-%d", i)
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       fmt.Sprintf("ns-%d", i%namespaces),
+			"uid":             fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			"resourceVersion": strconv.Itoa(i + 1),
+			"labels": map[string]interface{}{
+				"app":               fmt.Sprintf("app-%d", i%100),
+				"pod-template-hash": "5d8f7c9b6",
+			},
+		},
+		"spec": map[string]interface{}{
+			"nodeName": fmt.Sprintf("node-%d", i%20),
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "registry.example.com/app:1.0.0",
+					"env": []interface{}{
+						map[string]interface{}{"name": "POD_NAME", "value": name},
+						map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+					},
+					"resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+						"limits":   map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"podIP": fmt.Sprintf("10.0.%d.%d", (i/250)%250, i%250),
+		},
+	}}
+}
@@ -0,0 +1,154 @@
+package informer
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 估算对象内存时使用的64位运行时开销
+const (
+	objectOverhead   = 48 // Unstructured结构体及其指针
+	stringHeaderSize = 16
+	interfaceSize    = 16
+	sliceHeaderSize  = 24
+	mapHeaderSize    = 48
+	// map的每个bucket容纳8个string到interface{}的元素，外加tophash和溢出指针
+	mapBucketSize   = 8 + 8*(stringHeaderSize+interfaceSize) + 8
+	mapLoadFactor   = 6.5
+	boxedScalarSize = 8
+)
+
+// EstimateObjectSize 估算非结构化对象在堆上占用的字节数，用于内存预算，不追求精确
+func EstimateObjectSize(obj *unstructured.Unstructured) int64 {
+	if obj == nil {
+		return 0
+	}
+	return objectOverhead + estimateValueSize(obj.Object)
+}
+
+// estimateValueSize 估算interface{}槽位所引用的数据大小，不含槽位本身
+func estimateValueSize(v interface{}) int64 {
+	switch value := v.(type) {
+	case nil, bool:
+		return 0
+	case string:
+		return stringHeaderSize + int64(len(value))
+	case map[string]interface{}:
+		size := int64(mapHeaderSize)
+		if len(value) > 0 {
+			buckets := 1
+			for float64(len(value)) > mapLoadFactor*float64(buckets) {
+				buckets *= 2
+			}
+			size += int64(buckets) * mapBucketSize
+		}
+		for key, item := range value {
+			size += int64(len(key)) + estimateValueSize(item)
+		}
+		return size
+	case []interface{}:
+		size := int64(sliceHeaderSize) + int64(cap(value))*interfaceSize
+		for _, item := range value {
+			size += estimateValueSize(item)
+		}
+		return size
+	default:
+		return boxedScalarSize
+	}
+}
+
+// memoryHandler 根据Informer事件维护资源的估算内存
+// 定期resync时新旧对象是同一个指针，直接跳过避免重复遍历
+func memoryHandler(size *atomic.Int64) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				size.Add(EstimateObjectSize(u))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj == newObj {
+				return
+			}
+			oldU, oldOK := oldObj.(*unstructured.Unstructured)
+			newU, newOK := newObj.(*unstructured.Unstructured)
+			if oldOK && newOK {
+				size.Add(EstimateObjectSize(newU) - EstimateObjectSize(oldU))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				size.Add(-EstimateObjectSize(u))
+			}
+		},
+	}
+}
+
+// MemoryReporter 报告Informer缓存估算内存的ResourceCache，用于执行内存预算
+type MemoryReporter interface {
+	MemoryBytes(gvr schema.GroupVersionResource) int64
+	TotalMemoryBytes() int64
+}
+
+// errMemoryBudgetExceeded 同步中的资源超出内存预算并已被驱逐
+var errMemoryBudgetExceeded = errors.New("informer memory budget exceeded")
+
+// memoryBudgetSetter 支持在同步过程中执行内存预算的ResourceCache
+type memoryBudgetSetter interface {
+	SetMemoryBudget(budget int64, onExceeded func(gvr schema.GroupVersionResource, size int64) bool)
+}
+
+// SetMemoryBudget 设置所有Informer缓存的估算内存上限，需在启动Informer之前调用
+// 同步中的资源超出剩余预算时调用onExceeded，返回true表示资源已被驱逐，同步随之中止
+func (im *InformerManager) SetMemoryBudget(budget int64, onExceeded func(gvr schema.GroupVersionResource, size int64) bool) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	im.memoryBudget = budget
+	im.onBudgetExceeded = onExceeded
+}
+
+// checkSyncMemory 同步过程中检查已读取对象的估算内存，与其他Informer的内存之和超出预算时驱逐资源并中止同步，
+// 避免单个大资源在同步完成、定期检查生效之前耗尽内存；重新LIST时旧缓存会被替换，不计入
+func (im *InformerManager) checkSyncMemory(gvr schema.GroupVersionResource, syncBytes int64) error {
+	if im.memoryBudget <= 0 || im.onBudgetExceeded == nil {
+		return nil
+	}
+	others := im.TotalMemoryBytes() - im.MemoryBytes(gvr)
+	if others+syncBytes <= im.memoryBudget {
+		return nil
+	}
+	if !im.onBudgetExceeded(gvr, syncBytes) {
+		return nil
+	}
+	return fmt.Errorf("sync of %s aborted after %d bytes with %d bytes in use: %w",
+		gvr.String(), syncBytes, others, errMemoryBudgetExceeded)
+}
+
+// MemoryBytes 返回资源Informer缓存的估算内存，Informer未启动时返回0
+func (im *InformerManager) MemoryBytes(gvr schema.GroupVersionResource) int64 {
+	im.readyMutex.RLock()
+	defer im.readyMutex.RUnlock()
+	if size, exists := im.memoryBytes[gvr]; exists {
+		return size.Load()
+	}
+	return 0
+}
+
+// TotalMemoryBytes 返回所有Informer缓存的估算内存
+func (im *InformerManager) TotalMemoryBytes() int64 {
+	im.readyMutex.RLock()
+	defer im.readyMutex.RUnlock()
+	var total int64
+	for _, size := range im.memoryBytes {
+		total += size.Load()
+	}
+	return total
+}
@@ -0,0 +1,98 @@
+package informer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// Selector 列表请求的过滤条件，命中索引时通过ByIndex查询
+type Selector struct {
+	Labels   labels.Selector
+	Fields   fields.Selector
+	OwnerUID string
+
+	// 字段选择器中每个字段的取值器
+	extractors map[string]*fieldExtractor
+}
+
+// ParseSelector 解析labelSelector、fieldSelector和ownerUID，
+// fieldSelector的字段可以是任意对象路径（如spec.nodeName、status.phase）
+func ParseSelector(labelSelector, fieldSelector, ownerUID string) (Selector, error) {
+	selector := Selector{OwnerUID: ownerUID}
+
+	if labelSelector != "" {
+		parsed, err := labels.Parse(labelSelector)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid labelSelector: %v", err)
+		}
+		selector.Labels = parsed
+	}
+
+	if fieldSelector != "" {
+		parsed, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid fieldSelector: %v", err)
+		}
+		selector.Fields = parsed
+		selector.extractors = make(map[string]*fieldExtractor)
+		for _, requirement := range parsed.Requirements() {
+			extractor, err := newFieldExtractor(requirement.Field)
+			if err != nil {
+				return Selector{}, fmt.Errorf("invalid fieldSelector: %v", err)
+			}
+			selector.extractors[requirement.Field] = extractor
+		}
+	}
+
+	return selector, nil
+}
+
+// Empty 检查选择器是否没有任何条件
+func (s Selector) Empty() bool {
+	return (s.Labels == nil || s.Labels.Empty()) && (s.Fields == nil || s.Fields.Empty()) && s.OwnerUID == ""
+}
+
+// Matches 检查对象是否满足所有条件，缺失的字段视为空字符串
+func (s Selector) Matches(obj *unstructured.Unstructured) bool {
+	if s.Labels != nil && !s.Labels.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+
+	if s.Fields != nil {
+		for _, requirement := range s.Fields.Requirements() {
+			values := s.extractors[requirement.Field].values(obj)
+			if len(values) == 0 {
+				values = []string{""}
+			}
+			matched := false
+			for _, value := range values {
+				if value == requirement.Value {
+					matched = true
+					break
+				}
+			}
+			if matched == (requirement.Operator == selection.NotEquals) {
+				return false
+			}
+		}
+	}
+
+	if s.OwnerUID != "" {
+		owned := false
+		for _, owner := range obj.GetOwnerReferences() {
+			if string(owner.UID) == s.OwnerUID {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			return false
+		}
+	}
+
+	return true
+}
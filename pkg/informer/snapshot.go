@@ -0,0 +1,140 @@
+package informer
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// SnapshotStore 缓存快照存储接口
+type SnapshotStore interface {
+	// SaveList 保存指定资源的对象列表（列表的resourceVersion即watch恢复点）
+	SaveList(gvr schema.GroupVersionResource, list *unstructured.UnstructuredList) error
+	// LoadList 读取指定资源的快照，不存在时返回nil
+	LoadList(gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, time.Time, error)
+}
+
+// SetSnapshotStore 设置快照存储，需在启动Informer之前调用
+func (im *InformerManager) SetSnapshotStore(store SnapshotStore) {
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	im.snapshotStore = store
+}
+
+// loadSnapshot 读取指定资源的快照，失败时仅记录日志
+func (im *InformerManager) loadSnapshot(gvr schema.GroupVersionResource) *unstructured.UnstructuredList {
+	if im.snapshotStore == nil {
+		return nil
+	}
+
+	list, savedAt, err := im.snapshotStore.LoadList(gvr)
+	if err != nil {
+		klog.Warningf("Failed to load snapshot for %s: %v", gvr.String(), err)
+		return nil
+	}
+	if list == nil || list.GetResourceVersion() == "" {
+		return nil
+	}
+
+	klog.Infof("Restored %d objects for %s from snapshot saved at %v (resourceVersion %s)",
+		len(list.Items), gvr.String(), savedAt.Format(time.RFC3339), list.GetResourceVersion())
+	return list
+}
+
+// IsStale 检查指定资源的缓存是否仍是未重新同步的快照数据
+func (im *InformerManager) IsStale(gvr schema.GroupVersionResource) bool {
+	im.readyMutex.RLock()
+	staleFlag, exists := im.staleStatus[gvr]
+	im.readyMutex.RUnlock()
+
+	if !exists {
+		return false
+	}
+
+	return staleFlag.Load()
+}
+
+// markFresh 标记资源已与API服务器重新同步
+func (im *InformerManager) markFresh(gvr schema.GroupVersionResource) {
+	im.readyMutex.RLock()
+	staleFlag, exists := im.staleStatus[gvr]
+	im.readyMutex.RUnlock()
+
+	if !exists || !staleFlag.CompareAndSwap(true, false) {
+		return
+	}
+
+	klog.Infof("Snapshot data for %s resynced with API server", gvr.String())
+
+	im.statsMutex.Lock()
+	stat := im.stats.ResourceStats[gvr.String()]
+	stat.Stale = false
+	im.stats.ResourceStats[gvr.String()] = stat
+	im.statsMutex.Unlock()
+}
+
+// SaveSnapshots 将所有已同步Informer的store内容写入快照存储
+func (im *InformerManager) SaveSnapshots() {
+	im.mutex.RLock()
+	store := im.snapshotStore
+	informers := make(map[schema.GroupVersionResource]cache.SharedIndexInformer, len(im.informers))
+	for gvr, informer := range im.informers {
+		informers[gvr] = informer
+	}
+	im.mutex.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	startTime := time.Now()
+	saved := 0
+	for gvr, informer := range informers {
+		// 只保存已同步且非快照恢复的数据，避免刷新旧数据的保存时间
+		if !im.IsReady(gvr) || im.IsStale(gvr) {
+			continue
+		}
+
+		// 先读取resourceVersion再读取对象：恢复时从较旧的版本开始watch，
+		// 重放的事件对store是幂等的
+		resourceVersion := informer.LastSyncResourceVersion()
+		if resourceVersion == "" {
+			continue
+		}
+
+		objects := informer.GetStore().List()
+		list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, len(objects))}
+		list.SetResourceVersion(resourceVersion)
+		for _, obj := range objects {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				list.Items = append(list.Items, *u)
+			}
+		}
+
+		if err := store.SaveList(gvr, list); err != nil {
+			klog.Errorf("Failed to save snapshot for %s: %v", gvr.String(), err)
+			continue
+		}
+		saved++
+	}
+
+	klog.V(2).Infof("Saved snapshots for %d resources in %v", saved, time.Since(startTime))
+}
+
+// StartSnapshotLoop 定期保存快照，直到管理器关闭
+func (im *InformerManager) StartSnapshotLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-im.ctx.Done():
+			return
+		case <-ticker.C:
+			im.SaveSnapshots()
+		}
+	}
+}
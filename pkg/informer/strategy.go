@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jicki/crds-objects-browser/pkg/flight"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -29,6 +30,10 @@ type InformerStrategy struct {
 	ParallelPreloadCount int
 	// 缓存同步超时
 	CacheSyncTimeout time.Duration
+	// 所有Informer缓存的估算内存上限（字节），0表示不限制
+	MemoryBudgetBytes int64
+	// 内存预算检查间隔
+	MemoryCheckInterval time.Duration
 }
 
 // DefaultStrategy 默认策略
@@ -52,17 +57,25 @@ func DefaultStrategy() *InformerStrategy {
 		MaxConcurrentInformers: 50,
 		ParallelPreloadCount:   5,                // 并行预加载数量
 		CacheSyncTimeout:       20 * time.Second, // 缓存同步超时
+		MemoryCheckInterval:    10 * time.Second,
 	}
 }
 
 // StrategyManager 策略管理器
 type StrategyManager struct {
-	informerManager *InformerManager
-	strategy        *InformerStrategy
-	accessTracker   map[schema.GroupVersionResource]time.Time
-	accessMutex     sync.RWMutex
-	ctx             context.Context
-	cancel          context.CancelFunc
+	resourceCache ResourceCache
+	strategy      *InformerStrategy
+	accessTracker map[schema.GroupVersionResource]time.Time
+	accessMutex   sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// 被固定的资源不会被自动清理（如策略引擎依赖的资源）
+	pinned map[schema.GroupVersionResource]int
+	// 因超出内存预算被驱逐的资源及驱逐时的估算内存
+	evicted map[schema.GroupVersionResource]int64
+	// 合并相同资源的并发直接LIST，所有请求都取消后中止
+	liveGroup flight.Group[[]*unstructured.Unstructured]
 
 	// 性能优化相关
 	preloadComplete chan struct{}
@@ -70,13 +83,16 @@ type StrategyManager struct {
 }
 
 // NewStrategyManager 创建策略管理器
-func NewStrategyManager(informerManager *InformerManager, strategy *InformerStrategy) *StrategyManager {
+// resourceCache可以是InformerManager，也可以是其他ResourceCache实现（如离线转储）
+func NewStrategyManager(resourceCache ResourceCache, strategy *InformerStrategy) *StrategyManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	sm := &StrategyManager{
-		informerManager: informerManager,
+		resourceCache:   resourceCache,
 		strategy:        strategy,
 		accessTracker:   make(map[schema.GroupVersionResource]time.Time),
+		pinned:          make(map[schema.GroupVersionResource]int),
+		evicted:         make(map[schema.GroupVersionResource]int64),
 		ctx:             ctx,
 		cancel:          cancel,
 		preloadComplete: make(chan struct{}),
@@ -87,6 +103,14 @@ func NewStrategyManager(informerManager *InformerManager, strategy *InformerStra
 		go sm.startAutoCleanup()
 	}
 
+	// 启动内存预算检查，支持时同步过程中也检查预算
+	if strategy.MemoryBudgetBytes > 0 {
+		if setter, ok := resourceCache.(memoryBudgetSetter); ok {
+			setter.SetMemoryBudget(strategy.MemoryBudgetBytes, sm.evictSyncing)
+		}
+		go sm.startMemoryBudgetEnforcement()
+	}
+
 	return sm
 }
 
@@ -142,7 +166,7 @@ func (sm *StrategyManager) PreloadResources(resourceList []ResourceInfo) error {
 			defer func() { <-semaphore }()
 
 			klog.Infof("Preloading resource: %s", gvr.String())
-			if err := sm.informerManager.StartInformer(gvr, namespaced); err != nil {
+			if err := sm.resourceCache.StartInformer(gvr, namespaced); err != nil {
 				errorMutex.Lock()
 				errors = append(errors, fmt.Errorf("failed to preload resource %s: %v", gvr.String(), err))
 				errorMutex.Unlock()
@@ -181,13 +205,13 @@ func (sm *StrategyManager) WaitForPreloadComplete(timeout time.Duration) error {
 // EnsureInformer 确保Informer已启动（懒加载）
 func (sm *StrategyManager) EnsureInformer(gvr schema.GroupVersionResource, namespaced bool) error {
 	// 检查是否已经存在且就绪
-	if sm.informerManager.IsReady(gvr) {
+	if sm.resourceCache.IsReady(gvr) {
 		sm.updateAccessTime(gvr)
 		return nil
 	}
 
 	// 检查并发限制
-	stats := sm.informerManager.GetStats()
+	stats := sm.resourceCache.GetStats()
 	if stats.ActiveInformers >= sm.strategy.MaxConcurrentInformers {
 		klog.Warningf("Reached max concurrent informers limit (%d), cleaning up unused informers",
 			sm.strategy.MaxConcurrentInformers)
@@ -196,7 +220,7 @@ func (sm *StrategyManager) EnsureInformer(gvr schema.GroupVersionResource, names
 
 	// 启动Informer
 	klog.Infof("Lazy loading informer for: %s", gvr.String())
-	if err := sm.informerManager.StartInformer(gvr, namespaced); err != nil {
+	if err := sm.resourceCache.StartInformer(gvr, namespaced); err != nil {
 		return err
 	}
 
@@ -204,50 +228,98 @@ func (sm *StrategyManager) EnsureInformer(gvr schema.GroupVersionResource, names
 	return nil
 }
 
-// GetObjects 获取对象（带策略，优化版本）
-func (sm *StrategyManager) GetObjects(gvr schema.GroupVersionResource, namespace string, namespaced bool) ([]*unstructured.Unstructured, error) {
-	// 确保Informer已启动
-	if err := sm.EnsureInformer(gvr, namespaced); err != nil {
-		return nil, err
-	}
+// GetObjects 获取对象（带策略），Informer未就绪时直接分页LIST，ctx取消时中止直接LIST
+func (sm *StrategyManager) GetObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool) ([]*unstructured.Unstructured, error) {
+	objects, _, err := sm.GetObjectsWithSource(ctx, gvr, namespace, namespaced)
+	return objects, err
+}
 
-	// 快速检查是否已就绪
-	if sm.informerManager.IsReady(gvr) {
-		return sm.informerManager.GetObjects(gvr, namespace)
+// GetObjectsWithSource 获取对象并返回数据来源：Informer就绪时读取缓存，
+// 未就绪或因内存预算被驱逐时直接分页LIST，不等待同步
+func (sm *StrategyManager) GetObjectsWithSource(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool) ([]*unstructured.Unstructured, Source, error) {
+	lister, live, err := sm.fallbackLister(gvr, namespaced)
+	if err != nil {
+		return nil, "", err
+	}
+	if live {
+		objects, err := sm.listLive(ctx, lister, gvr, namespace, namespaced, "")
+		return objects, SourceLive, err
 	}
 
-	// 等待缓存同步（带超时）
-	ctx, cancel := context.WithTimeout(sm.ctx, sm.strategy.CacheSyncTimeout)
-	defer cancel()
+	if err := sm.waitForSync(gvr); err != nil {
+		return nil, "", err
+	}
+	objects, err := sm.resourceCache.GetObjects(gvr, namespace)
+	return objects, SourceCache, err
+}
 
-	ticker := time.NewTicker(50 * time.Millisecond) // 减少轮询间隔
-	defer ticker.Stop()
+// GetObjectsByIndex 通过索引获取对象（带策略）
+func (sm *StrategyManager) GetObjectsByIndex(ctx context.Context, gvr schema.GroupVersionResource, namespaced bool, indexName, value string) ([]*unstructured.Unstructured, error) {
+	objects, _, err := sm.getObjectsByIndex(ctx, gvr, namespaced, indexName, value)
+	return objects, err
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("timeout waiting for cache sync for %s after %v", gvr.String(), sm.strategy.CacheSyncTimeout)
-		case <-ticker.C:
-			if sm.informerManager.IsReady(gvr) {
-				return sm.informerManager.GetObjects(gvr, namespace)
-			}
+// getObjectsByIndex 通过索引获取对象并返回数据来源，直接LIST时用索引函数过滤
+func (sm *StrategyManager) getObjectsByIndex(ctx context.Context, gvr schema.GroupVersionResource, namespaced bool, indexName, value string) ([]*unstructured.Unstructured, Source, error) {
+	lister, live, err := sm.fallbackLister(gvr, namespaced)
+	if err != nil {
+		return nil, "", err
+	}
+	if live {
+		// 服务器支持的字段交给API服务器过滤，仍然用索引函数过滤保证与ByIndex结果一致
+		objects, err := sm.listLive(ctx, lister, gvr, "", namespaced, liveFieldSelector(gvr, indexName, value))
+		if err != nil {
+			return nil, "", err
 		}
+		objects, err = filterByIndex(gvr, objects, indexName, value)
+		return objects, SourceLive, err
 	}
+
+	if err := sm.waitForSync(gvr); err != nil {
+		return nil, "", err
+	}
+	objects, err := sm.resourceCache.GetObjectsByIndex(gvr, indexName, value)
+	return objects, SourceCache, err
 }
 
-// GetNamespaces 获取命名空间（带策略，优化版本）
-func (sm *StrategyManager) GetNamespaces(gvr schema.GroupVersionResource, namespaced bool) ([]string, error) {
-	// 确保Informer已启动
-	if err := sm.EnsureInformer(gvr, namespaced); err != nil {
-		return nil, err
+// SelectObjects 按选择器获取对象并返回数据来源，选择器命中已声明的索引时通过ByIndex读取，否则读取后过滤
+func (sm *StrategyManager) SelectObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool, selector Selector) ([]*unstructured.Unstructured, Source, error) {
+	if selector.Empty() {
+		return sm.GetObjectsWithSource(ctx, gvr, namespace, namespaced)
 	}
 
-	// 快速检查是否已就绪
-	if sm.informerManager.IsReady(gvr) {
-		return sm.informerManager.GetNamespaces(gvr)
+	var objects []*unstructured.Unstructured
+	var source Source
+	var err error
+	indexName, value, indexed := DefaultIndexRegistry().Match(gvr, selector)
+	if indexed {
+		klog.V(4).Infof("Selecting %s by index %s=%s", gvr.String(), indexName, value)
+		objects, source, err = sm.getObjectsByIndex(ctx, gvr, namespaced, indexName, value)
+	} else {
+		objects, source, err = sm.GetObjectsWithSource(ctx, gvr, namespace, namespaced)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if indexed && namespace != "" && namespace != "all" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if selector.Matches(obj) {
+			result = append(result, obj)
+		}
+	}
+	return result, source, nil
+}
+
+// waitForSync 等待资源的缓存同步（带超时）
+func (sm *StrategyManager) waitForSync(gvr schema.GroupVersionResource) error {
+	if sm.resourceCache.IsReady(gvr) {
+		return nil
 	}
 
-	// 等待缓存同步
 	ctx, cancel := context.WithTimeout(sm.ctx, sm.strategy.CacheSyncTimeout)
 	defer cancel()
 
@@ -257,30 +329,39 @@ func (sm *StrategyManager) GetNamespaces(gvr schema.GroupVersionResource, namesp
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("timeout waiting for cache sync for %s after %v", gvr.String(), sm.strategy.CacheSyncTimeout)
+			return fmt.Errorf("timeout waiting for cache sync for %s after %v", gvr.String(), sm.strategy.CacheSyncTimeout)
 		case <-ticker.C:
-			if sm.informerManager.IsReady(gvr) {
-				return sm.informerManager.GetNamespaces(gvr)
+			if sm.resourceCache.IsReady(gvr) {
+				return nil
 			}
 		}
 	}
 }
 
-// GetObjectsWithFallback 获取对象（带降级策略）
-func (sm *StrategyManager) GetObjectsWithFallback(gvr schema.GroupVersionResource, namespace string, namespaced bool) ([]*unstructured.Unstructured, error) {
-	// 首先尝试从缓存获取
-	if sm.informerManager.IsReady(gvr) {
-		sm.updateAccessTime(gvr)
-		return sm.informerManager.GetObjects(gvr, namespace)
+// GetNamespaces 获取命名空间（带策略），Informer未就绪时从直接LIST的结果中收集
+func (sm *StrategyManager) GetNamespaces(ctx context.Context, gvr schema.GroupVersionResource, namespaced bool) ([]string, error) {
+	lister, live, err := sm.fallbackLister(gvr, namespaced)
+	if err != nil {
+		return nil, err
+	}
+	if live {
+		objects, err := sm.listLive(ctx, lister, gvr, "", namespaced, "")
+		if err != nil {
+			return nil, err
+		}
+		return namespacesOf(objects), nil
 	}
 
-	// 如果缓存未就绪，启动Informer但立即返回空结果
-	if err := sm.EnsureInformer(gvr, namespaced); err != nil {
-		klog.Warningf("Failed to ensure informer for %s: %v", gvr.String(), err)
+	if err := sm.waitForSync(gvr); err != nil {
+		return nil, err
 	}
+	return sm.resourceCache.GetNamespaces(gvr)
+}
 
-	// 返回空结果，让前端知道数据正在加载
-	return []*unstructured.Unstructured{}, nil
+// RecordAccess 记录对资源缓存数据的访问，用于响应缓存命中等不经过StrategyManager的读取，
+// 避免仍在使用的Informer被当作空闲清理或驱逐
+func (sm *StrategyManager) RecordAccess(gvr schema.GroupVersionResource) {
+	sm.updateAccessTime(gvr)
 }
 
 // updateAccessTime 更新访问时间
@@ -312,14 +393,11 @@ func (sm *StrategyManager) cleanupUnusedInformers() {
 	var toCleanup []schema.GroupVersionResource
 
 	// 创建预加载资源的快速查找映射
-	preloadedMap := make(map[schema.GroupVersionResource]bool)
-	for _, preloadGvr := range sm.strategy.PreloadResources {
-		preloadedMap[preloadGvr] = true
-	}
+	preloadedMap := sm.preloadedSet()
 
 	for gvr, lastAccess := range sm.accessTracker {
-		// 预加载资源不清理
-		if preloadedMap[gvr] {
+		// 预加载和被固定的资源不清理
+		if preloadedMap[gvr] || sm.pinned[gvr] > 0 {
 			continue
 		}
 
@@ -336,7 +414,7 @@ func (sm *StrategyManager) cleanupUnusedInformers() {
 
 		for _, gvr := range toCleanup {
 			klog.V(4).Infof("Cleaning up unused informer: %s", gvr.String())
-			sm.informerManager.StopInformer(gvr)
+			sm.resourceCache.StopInformer(gvr)
 
 			sm.accessMutex.Lock()
 			delete(sm.accessTracker, gvr)
@@ -345,9 +423,52 @@ func (sm *StrategyManager) cleanupUnusedInformers() {
 	}
 }
 
+// Pin 启动并固定指定资源的Informer，使其不被自动清理，可重复调用，需与Unpin成对使用
+func (sm *StrategyManager) Pin(gvr schema.GroupVersionResource, namespaced bool) error {
+	// 固定的资源必须有Informer，即使之前因内存预算被驱逐
+	sm.accessMutex.Lock()
+	delete(sm.evicted, gvr)
+	sm.accessMutex.Unlock()
+
+	if err := sm.EnsureInformer(gvr, namespaced); err != nil {
+		return err
+	}
+
+	sm.accessMutex.Lock()
+	sm.pinned[gvr]++
+	sm.accessMutex.Unlock()
+	return nil
+}
+
+// Unpin 取消固定，计数归零后恢复正常的自动清理
+func (sm *StrategyManager) Unpin(gvr schema.GroupVersionResource) {
+	sm.accessMutex.Lock()
+	defer sm.accessMutex.Unlock()
+
+	if sm.pinned[gvr] <= 1 {
+		delete(sm.pinned, gvr)
+		return
+	}
+	sm.pinned[gvr]--
+}
+
 // GetCacheStats 获取缓存统计信息
 func (sm *StrategyManager) GetCacheStats() CacheStats {
-	stats := sm.informerManager.GetStats()
+	stats := sm.resourceCache.GetStats()
+
+	// 标记被驱逐的资源，复制一份避免修改缓存内部的统计
+	resourceStats := make(map[string]ResourceStat, len(stats.ResourceStats))
+	for key, stat := range stats.ResourceStats {
+		resourceStats[key] = stat
+	}
+	stats.ResourceStats = resourceStats
+	sm.accessMutex.RLock()
+	for gvr := range sm.evicted {
+		stat := stats.ResourceStats[gvr.String()]
+		stat.Evicted = true
+		stats.ResourceStats[gvr.String()] = stat
+	}
+	sm.accessMutex.RUnlock()
 
 	// 添加访问统计
 	sm.accessMutex.RLock()
@@ -363,9 +484,24 @@ func (sm *StrategyManager) GetCacheStats() CacheStats {
 	return stats
 }
 
+// IsStale 检查资源缓存是否仍为未重新同步的快照数据
+func (sm *StrategyManager) IsStale(gvr schema.GroupVersionResource) bool {
+	if staleChecker, ok := sm.resourceCache.(interface {
+		IsStale(gvr schema.GroupVersionResource) bool
+	}); ok {
+		return staleChecker.IsStale(gvr)
+	}
+	return false
+}
+
+// IsReady 检查指定资源的缓存是否已就绪，不会触发懒加载
+func (sm *StrategyManager) IsReady(gvr schema.GroupVersionResource) bool {
+	return sm.resourceCache.IsReady(gvr)
+}
+
 // GetReadyResourcesCount 获取就绪资源数量
 func (sm *StrategyManager) GetReadyResourcesCount() int {
-	stats := sm.informerManager.GetStats()
+	stats := sm.resourceCache.GetStats()
 	readyCount := 0
 	for _, isReady := range stats.SyncStatus {
 		if isReady {
@@ -389,7 +525,9 @@ func (sm *StrategyManager) IsPreloadComplete() bool {
 func (sm *StrategyManager) Shutdown() {
 	klog.Info("Shutting down strategy manager")
 	sm.cancel()
-	sm.informerManager.Shutdown()
+	if shutdowner, ok := sm.resourceCache.(interface{ Shutdown() }); ok {
+		shutdowner.Shutdown()
+	}
 }
 
 // ResourceInfo 资源信息
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jicki/crds-objects-browser/pkg/deprecation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -368,6 +369,7 @@ func (c *Client) GetCRDs() ([]CRDResource, error) {
 	}
 
 	crds = append(crds, coreResources...)
+	served := deprecation.ServedResources(resources)
 
 	for _, list := range resources {
 		if list == nil || list.APIResources == nil {
@@ -391,12 +393,12 @@ func (c *Client) GetCRDs() ([]CRDResource, error) {
 			}
 
 			// 排除特殊资源
-			if isSpecialResource(r.Name, gv.Group) {
+			if deprecation.Default().IsExcluded(gv.Group, r.Name) {
 				continue
 			}
 
-			// 排除已弃用的资源，避免Kubernetes警告
-			if isDeprecatedResource(r.Name, gv.Group, gv.Version) {
+			// 排除替代版本同样可用的弃用资源，避免Kubernetes警告
+			if deprecation.Default().IsSuperseded(gv.Group, gv.Version, r.Name, served) {
 				continue
 			}
 
@@ -465,72 +467,6 @@ func hasVerb(r metav1.APIResource, verb string) bool {
 	return false
 }
 
-// isSpecialResource 检查是否为特殊资源（需要排除的资源）
-func isSpecialResource(name, group string) bool {
-	specialResources := map[string][]string{
-		"": {
-			"componentstatuses", // 排除已弃用的ComponentStatus
-		},
-		"authorization.k8s.io": {
-			"selfsubjectrulesreviews",
-			"subjectaccessreviews",
-			"localsubjectaccessreviews",
-			"selfsubjectaccessreviews",
-		},
-		"authentication.k8s.io": {
-			"tokenreviews",
-		},
-		"metrics.k8s.io": {
-			"pods",
-			"nodes",
-		},
-		// 排除已弃用的资源，避免Kubernetes警告
-		"policy": {
-			"podsecuritypolicies", // 在v1.21+中已弃用，在v1.25+中不可用
-		},
-		"extensions": {
-			"podsecuritypolicies", // 也可能在extensions组中
-		},
-	}
-
-	if resources, ok := specialResources[group]; ok {
-		for _, r := range resources {
-			if r == name {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// isDeprecatedResource 检查是否为已弃用的资源
-func isDeprecatedResource(name, group, version string) bool {
-	// 已弃用的资源列表
-	deprecatedResources := map[string]map[string][]string{
-		"policy": {
-			"v1beta1": {"podsecuritypolicies"},
-		},
-		"extensions": {
-			"v1beta1": {"podsecuritypolicies", "deployments", "replicasets", "daemonsets"},
-		},
-		"apps": {
-			"v1beta1": {"deployments", "replicasets", "daemonsets"},
-			"v1beta2": {"deployments", "replicasets", "daemonsets"},
-		},
-	}
-
-	if groupMap, ok := deprecatedResources[group]; ok {
-		if versionList, ok := groupMap[version]; ok {
-			for _, r := range versionList {
-				if r == name {
-					return true
-				}
-			}
-		}
-	}
-	return false
-}
-
 // 检查是否为内置资源
 func isInternalResource(name, group string) bool {
 	// 允许显示的核心资源
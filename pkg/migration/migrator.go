@@ -0,0 +1,442 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+)
+
+// crdGVR CRD资源
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// fieldManager 迁移写入使用的字段管理器
+const fieldManager = "crds-objects-browser-migrator"
+
+// maxRecordedFailures 状态中保留的失败对象数上限
+const maxRecordedFailures = 100
+
+// Phase 迁移阶段
+type Phase string
+
+const (
+	PhaseRunning     Phase = "Running"
+	PhaseCompleted   Phase = "Completed"
+	PhaseFailed      Phase = "Failed"
+	PhaseCancelled   Phase = "Cancelled"
+	PhaseInterrupted Phase = "Interrupted"
+)
+
+// StateStore 迁移状态的持久化存储，用于重启后恢复
+type StateStore interface {
+	SaveValue(name string, value interface{}) error
+	LoadValue(name string, out interface{}) (bool, error)
+}
+
+// Target 迁移目标
+type Target struct {
+	CRD            string `json:"crd"`
+	Group          string `json:"group"`
+	Resource       string `json:"resource"`
+	StorageVersion string `json:"storageVersion"`
+	Namespaced     bool   `json:"namespaced"`
+}
+
+// Failure 迁移失败的对象
+type Failure struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Error     string `json:"error"`
+}
+
+// State 迁移进度
+type State struct {
+	Target
+	Phase       Phase      `json:"phase"`
+	Message     string     `json:"message,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+
+	// Continue 下一页的LIST continue令牌，恢复时从这里继续
+	Continue  string    `json:"continue,omitempty"`
+	Processed int       `json:"processed"`
+	Migrated  int       `json:"migrated"`
+	Skipped   int       `json:"skipped"`
+	Failed    int       `json:"failed"`
+	Failures  []Failure `json:"failures,omitempty"`
+
+	StoredVersionsUpdated bool `json:"storedVersionsUpdated"`
+}
+
+// Options 迁移选项
+type Options struct {
+	// QPS 每秒重写的对象数
+	QPS float32 `json:"qps"`
+	// Burst 令牌桶容量
+	Burst int `json:"burst"`
+	// PageSize 每页LIST的对象数
+	PageSize int64 `json:"pageSize"`
+}
+
+// DefaultOptions 默认迁移选项
+func DefaultOptions() Options {
+	return Options{
+		QPS:      10,
+		Burst:    10,
+		PageSize: 100,
+	}
+}
+
+// Migrator 存储版本迁移器
+// API服务器不暴露单个对象在etcd中的编码版本，因此只要status.storedVersions中还有旧版本，
+// 所有对象都视为待迁移：以存储版本对每个对象做一次不修改内容的更新，使其按存储版本重新写入，
+// 全部成功后把status.storedVersions收敛为存储版本。
+type Migrator struct {
+	client dynamic.Interface
+	store  StateStore
+
+	mutex   sync.RWMutex
+	states  map[string]*State
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewMigrator 创建迁移器，store为nil时状态只保存在内存中
+func NewMigrator(client dynamic.Interface, store StateStore) *Migrator {
+	return &Migrator{
+		client:  client,
+		store:   store,
+		states:  make(map[string]*State),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// stateKey 迁移状态在存储中的键
+func stateKey(crd string) string {
+	return "migration/" + crd
+}
+
+// Start 开始迁移；resume为true时从上次保存的进度继续
+func (m *Migrator) Start(target Target, options Options, resume bool) (State, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, running := m.cancels[target.CRD]; running {
+		return State{}, fmt.Errorf("migration for %s is already running", target.CRD)
+	}
+
+	now := time.Now()
+	state := &State{Target: target, StartedAt: now}
+	if resume {
+		previous, ok := m.loadStateLocked(target.CRD)
+		if !ok {
+			return State{}, fmt.Errorf("no previous migration for %s to resume", target.CRD)
+		}
+		if previous.StorageVersion != target.StorageVersion {
+			return State{}, fmt.Errorf("storage version changed from %s to %s, start a new migration", previous.StorageVersion, target.StorageVersion)
+		}
+		// 已完成或失败的迁移从头重新处理，失败计数清零
+		if previous.Phase == PhaseCompleted || previous.Phase == PhaseFailed {
+			previous.Continue = ""
+			previous.Processed, previous.Migrated, previous.Skipped, previous.Failed = 0, 0, 0, 0
+			previous.Failures = nil
+		}
+		state = &previous
+		state.Target = target
+	}
+	state.Phase = PhaseRunning
+	state.Message = ""
+	state.UpdatedAt = now
+	state.CompletedAt = nil
+	state.StoredVersionsUpdated = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.states[target.CRD] = state
+	m.cancels[target.CRD] = cancel
+	m.saveLocked(state)
+
+	m.wg.Add(1)
+	go m.run(ctx, state, options)
+
+	klog.Infof("Started storage version migration for %s to %s (resume=%v)", target.CRD, target.StorageVersion, resume)
+	return *state, nil
+}
+
+// Cancel 取消正在运行的迁移
+func (m *Migrator) Cancel(crd string) bool {
+	m.mutex.RLock()
+	cancel, ok := m.cancels[crd]
+	m.mutex.RUnlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Get 返回迁移状态，包括上次运行保存在存储中的状态
+func (m *Migrator) Get(crd string) (State, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.loadStateLocked(crd)
+}
+
+// List 返回本次运行以来的所有迁移状态
+func (m *Migrator) List() []State {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	states := make([]State, 0, len(m.states))
+	for _, state := range m.states {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].CRD < states[j].CRD })
+	return states
+}
+
+// Shutdown 停止所有迁移并等待进度保存
+func (m *Migrator) Shutdown() {
+	m.mutex.RLock()
+	for _, cancel := range m.cancels {
+		cancel()
+	}
+	m.mutex.RUnlock()
+	m.wg.Wait()
+}
+
+// loadStateLocked 从内存或存储读取状态，存储中处于运行状态但本进程未运行的迁移标记为中断
+func (m *Migrator) loadStateLocked(crd string) (State, bool) {
+	if state, ok := m.states[crd]; ok {
+		return *state, true
+	}
+	if m.store == nil {
+		return State{}, false
+	}
+
+	var state State
+	found, err := m.store.LoadValue(stateKey(crd), &state)
+	if err != nil {
+		klog.Warningf("Failed to load migration state for %s: %v", crd, err)
+		return State{}, false
+	}
+	if !found {
+		return State{}, false
+	}
+	if state.Phase == PhaseRunning {
+		state.Phase = PhaseInterrupted
+		state.Message = "server stopped while the migration was running, resume to continue"
+	}
+	return state, true
+}
+
+// saveLocked 保存状态到存储
+func (m *Migrator) saveLocked(state *State) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SaveValue(stateKey(state.CRD), state); err != nil {
+		klog.Warningf("Failed to save migration state for %s: %v", state.CRD, err)
+	}
+}
+
+// update 在锁内修改状态并保存
+func (m *Migrator) update(state *State, fn func(state *State)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	fn(state)
+	state.UpdatedAt = time.Now()
+	m.saveLocked(state)
+}
+
+// modify 在锁内修改状态但不保存，用于逐对象的计数；计数随每页结束时的continue令牌一起保存
+func (m *Migrator) modify(state *State, fn func(state *State)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	fn(state)
+	state.UpdatedAt = time.Now()
+}
+
+// pageCheckpoint 一页开始时的计数，迁移在页中途中止时恢复为该计数，
+// 使保存的计数与continue令牌一致，恢复时重新处理这一页不会重复计数
+type pageCheckpoint struct {
+	processed, migrated, skipped, failed int
+	failures                             []Failure
+}
+
+// checkpoint 记录当前计数
+func (m *Migrator) checkpoint(state *State) *pageCheckpoint {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return &pageCheckpoint{
+		processed: state.Processed,
+		migrated:  state.Migrated,
+		skipped:   state.Skipped,
+		failed:    state.Failed,
+		failures:  append([]Failure(nil), state.Failures...),
+	}
+}
+
+// restore 将计数恢复为一页开始时的值，调用方需持有锁
+func (p *pageCheckpoint) restore(state *State) {
+	state.Processed, state.Migrated, state.Skipped, state.Failed = p.processed, p.migrated, p.skipped, p.failed
+	state.Failures = p.failures
+}
+
+// run 分页重写所有对象
+func (m *Migrator) run(ctx context.Context, state *State, options Options) {
+	defer m.wg.Done()
+	defer func() {
+		m.mutex.Lock()
+		delete(m.cancels, state.CRD)
+		m.mutex.Unlock()
+	}()
+
+	defaults := DefaultOptions()
+	if options.QPS <= 0 {
+		options.QPS = defaults.QPS
+	}
+	if options.Burst <= 0 {
+		options.Burst = defaults.Burst
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = defaults.PageSize
+	}
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(options.QPS, options.Burst)
+	defer limiter.Stop()
+
+	gvr := schema.GroupVersionResource{Group: state.Group, Version: state.StorageVersion, Resource: state.Resource}
+	resource := m.client.Resource(gvr)
+
+	for {
+		checkpoint := m.checkpoint(state)
+		list, err := resource.List(ctx, metav1.ListOptions{Limit: options.PageSize, Continue: state.Continue})
+		if apierrors.IsResourceExpired(err) {
+			// continue令牌过期：重写是幂等的，从头开始即可，计数同样清零
+			klog.Warningf("Continue token for %s migration expired, restarting listing", state.CRD)
+			m.update(state, func(state *State) {
+				state.Continue = ""
+				state.Processed, state.Migrated, state.Skipped, state.Failed = 0, 0, 0, 0
+				state.Failures = nil
+			})
+			continue
+		}
+		if err != nil {
+			m.finish(ctx, state, checkpoint, fmt.Errorf("failed to list %s: %v", gvr.String(), err))
+			return
+		}
+
+		for i := range list.Items {
+			if err := limiter.Wait(ctx); err != nil {
+				m.finish(ctx, state, checkpoint, err)
+				return
+			}
+			m.rewrite(ctx, resource, state, &list.Items[i])
+		}
+
+		m.update(state, func(state *State) { state.Continue = list.GetContinue() })
+		if state.Continue == "" {
+			break
+		}
+	}
+
+	m.finish(ctx, state, nil, nil)
+}
+
+// rewrite 以存储版本对对象做一次不修改内容的更新，冲突时重新读取后重试一次
+func (m *Migrator) rewrite(ctx context.Context, resource dynamic.NamespaceableResourceInterface, state *State, obj *unstructured.Unstructured) {
+	var client dynamic.ResourceInterface = resource
+	if state.Namespaced {
+		client = resource.Namespace(obj.GetNamespace())
+	}
+
+	options := metav1.UpdateOptions{FieldManager: fieldManager}
+	_, err := client.Update(ctx, obj, options)
+	if apierrors.IsConflict(err) {
+		var latest *unstructured.Unstructured
+		if latest, err = client.Get(ctx, obj.GetName(), metav1.GetOptions{}); err == nil {
+			_, err = client.Update(ctx, latest, options)
+		}
+	}
+
+	m.modify(state, func(state *State) {
+		state.Processed++
+		switch {
+		case err == nil:
+			state.Migrated++
+		case apierrors.IsNotFound(err):
+			// 迁移过程中被删除的对象无需处理
+			state.Skipped++
+		default:
+			state.Failed++
+			if len(state.Failures) < maxRecordedFailures {
+				state.Failures = append(state.Failures, Failure{Namespace: obj.GetNamespace(), Name: obj.GetName(), Error: err.Error()})
+			}
+		}
+	})
+}
+
+// finish 结束迁移；全部对象成功重写时更新CRD的status.storedVersions
+// checkpoint不为空表示迁移在页中途中止，计数恢复为该页开始时的值后再保存
+func (m *Migrator) finish(ctx context.Context, state *State, checkpoint *pageCheckpoint, err error) {
+	phase := PhaseCompleted
+	message := ""
+	storedVersionsUpdated := false
+
+	switch {
+	case ctx.Err() != nil:
+		phase = PhaseCancelled
+		message = "migration cancelled, resume to continue"
+	case err != nil:
+		phase = PhaseFailed
+		message = err.Error()
+	case state.Failed > 0:
+		phase = PhaseFailed
+		message = fmt.Sprintf("%d objects failed to migrate, status.storedVersions left unchanged", state.Failed)
+	default:
+		if updateErr := m.updateStoredVersions(ctx, state); updateErr != nil {
+			phase = PhaseFailed
+			message = fmt.Sprintf("all objects migrated but failed to update status.storedVersions: %v", updateErr)
+		} else {
+			storedVersionsUpdated = true
+		}
+	}
+
+	m.update(state, func(state *State) {
+		if checkpoint != nil {
+			checkpoint.restore(state)
+		}
+		now := time.Now()
+		state.Phase = phase
+		state.Message = message
+		state.CompletedAt = &now
+		state.StoredVersionsUpdated = storedVersionsUpdated
+	})
+
+	klog.Infof("Storage version migration for %s finished: %s (migrated=%d, skipped=%d, failed=%d)",
+		state.CRD, phase, state.Migrated, state.Skipped, state.Failed)
+}
+
+// updateStoredVersions 将CRD的status.storedVersions设置为只包含存储版本
+func (m *Migrator) updateStoredVersions(ctx context.Context, state *State) error {
+	crds := m.client.Resource(crdGVR)
+	crd, err := crds.Get(ctx, state.CRD, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedStringSlice(crd.Object, []string{state.StorageVersion}, "status", "storedVersions"); err != nil {
+		return err
+	}
+	_, err = crds.UpdateStatus(ctx, crd, metav1.UpdateOptions{FieldManager: fieldManager})
+	return err
+}
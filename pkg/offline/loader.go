@@ -0,0 +1,174 @@
+package offline
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+)
+
+// loader 从目录或归档中收集对象
+type loader struct {
+	objects []*unstructured.Unstructured
+	files   int
+	skipped int
+}
+
+// loadPath 根据路径类型（目录、tar、tar.gz）加载所有对象
+func (l *loader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+
+	if info.IsDir() {
+		return l.loadDir(path)
+	}
+
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return l.loadTarball(path, true)
+	case strings.HasSuffix(lower, ".tar"):
+		return l.loadTarball(path, false)
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return l.loadStream(path, f)
+	}
+}
+
+// loadDir 递归加载目录中的YAML/JSON文件
+func (l *loader) loadDir(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isManifestFile(path) {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return l.loadStream(path, f)
+	})
+}
+
+// loadTarball 加载tar归档（must-gather通常为tar.gz）中的YAML/JSON文件
+func (l *loader) loadTarball(path string, gzipped bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip archive %s: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %v", path, err)
+		}
+		if header.Typeflag != tar.TypeReg || !isManifestFile(header.Name) {
+			continue
+		}
+		if err := l.loadStream(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// loadStream 解析单个文件中的多个YAML文档或JSON对象
+func (l *loader) loadStream(name string, r io.Reader) error {
+	l.files++
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			// 单个文件解析失败不影响其他文件
+			klog.Warningf("Skipping unparsable file %s: %v", name, err)
+			l.skipped++
+			return nil
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		var content map[string]interface{}
+		if err := utiljson.Unmarshal(raw, &content); err != nil {
+			klog.V(4).Infof("Skipping non-object document in %s: %v", name, err)
+			l.skipped++
+			continue
+		}
+		l.addObject(&unstructured.Unstructured{Object: content})
+	}
+}
+
+// addObject 添加对象，List类型会展开为其中的元素
+func (l *loader) addObject(obj *unstructured.Unstructured) {
+	if items, ok := obj.Object["items"].([]interface{}); ok && (obj.IsList() || strings.HasSuffix(obj.GetKind(), "List")) {
+		// kubectl输出的类型化List中元素可能缺少apiVersion和kind
+		itemKind := strings.TrimSuffix(obj.GetKind(), "List")
+		for _, item := range items {
+			content, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			child := &unstructured.Unstructured{Object: content}
+			if child.GetAPIVersion() == "" {
+				child.SetAPIVersion(obj.GetAPIVersion())
+			}
+			if child.GetKind() == "" && itemKind != "" && itemKind != obj.GetKind() {
+				child.SetKind(itemKind)
+			}
+			l.addObject(child)
+		}
+		return
+	}
+
+	if obj.GetKind() == "" || obj.GetAPIVersion() == "" || obj.GetName() == "" {
+		l.skipped++
+		return
+	}
+	l.objects = append(l.objects, obj)
+}
+
+// isManifestFile 判断文件是否为YAML或JSON清单
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// ErrSnapshotNotFound 命名快照不存在
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+var (
+	// resourcesBucket 保存每个GVR的对象快照，每个GVR一个子bucket
+	resourcesBucket = []byte("resources")
+	// metaBucket 保存快照元数据（resourceVersion、保存时间等）
+	metaBucket = []byte("meta")
+	// namedBucket 保存命名快照，每个快照一个子bucket，其下每个GVR一个子bucket
+	namedBucket = []byte("named-snapshots")
+	// namedMetaBucket 保存命名快照的元数据
+	namedMetaBucket = []byte("named-snapshot-meta")
+)
+
+// SnapshotResource 命名快照中包含的资源
+type SnapshotResource struct {
+	Group       string `json:"group"`
+	Version     string `json:"version"`
+	Resource    string `json:"resource"`
+	ObjectCount int    `json:"objectCount"`
+}
+
+// GroupVersionResource 返回资源的GVR
+func (r SnapshotResource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// NamedSnapshot 命名快照的元数据
+type NamedSnapshot struct {
+	Name        string             `json:"name"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	Namespace   string             `json:"namespace,omitempty"`
+	Resources   []SnapshotResource `json:"resources"`
+	ObjectCount int                `json:"objectCount"`
+}
+
+// resourceMeta 单个资源快照的元数据
+type resourceMeta struct {
+	ResourceVersion string    `json:"resourceVersion"`
+	SavedAt         time.Time `json:"savedAt"`
+	ObjectCount     int       `json:"objectCount"`
+}
+
+// Store 基于bbolt的本地快照存储
+type Store struct {
+	db   *bolt.DB
+	path string
+}
+
+// Open 打开（或创建）快照文件
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot store %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{resourcesBucket, metaBucket, namedBucket, namedMetaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize snapshot store %s: %v", path, err)
+	}
+
+	klog.Infof("Opened snapshot store at %s", path)
+	return &Store{db: db, path: path}, nil
+}
+
+// Path 返回快照文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SaveList 保存指定资源的完整对象列表，覆盖旧快照
+func (s *Store) SaveList(gvr schema.GroupVersionResource, list *unstructured.UnstructuredList) error {
+	key := []byte(gvr.String())
+
+	meta, err := json.Marshal(resourceMeta{
+		ResourceVersion: list.GetResourceVersion(),
+		SavedAt:         time.Now(),
+		ObjectCount:     len(list.Items),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		resources := tx.Bucket(resourcesBucket)
+
+		// 先删除旧数据，保证快照与store内容一致
+		if resources.Bucket(key) != nil {
+			if err := resources.DeleteBucket(key); err != nil {
+				return err
+			}
+		}
+		bucket, err := resources.CreateBucket(key)
+		if err != nil {
+			return err
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			data, err := obj.MarshalJSON()
+			if err != nil {
+				return fmt.Errorf("failed to encode %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+			}
+			if err := bucket.Put([]byte(objectKey(obj)), data); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(metaBucket).Put(key, meta)
+	})
+}
+
+// LoadList 读取指定资源的快照，不存在时返回nil
+func (s *Store) LoadList(gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, time.Time, error) {
+	key := []byte(gvr.String())
+	var list *unstructured.UnstructuredList
+	var savedAt time.Time
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		rawMeta := tx.Bucket(metaBucket).Get(key)
+		bucket := tx.Bucket(resourcesBucket).Bucket(key)
+		if rawMeta == nil || bucket == nil {
+			return nil
+		}
+
+		var meta resourceMeta
+		if err := json.Unmarshal(rawMeta, &meta); err != nil {
+			return fmt.Errorf("failed to decode snapshot metadata: %v", err)
+		}
+
+		list = &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, meta.ObjectCount)}
+		list.SetResourceVersion(meta.ResourceVersion)
+		savedAt = meta.SavedAt
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var obj unstructured.Unstructured
+			if err := obj.UnmarshalJSON(v); err != nil {
+				return fmt.Errorf("failed to decode %s: %v", string(k), err)
+			}
+			list.Items = append(list.Items, obj)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return list, savedAt, nil
+}
+
+// SaveValue 保存任意JSON值（例如发现的资源列表）
+func (s *Store) SaveValue(name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put([]byte("value:"+name), data)
+	})
+}
+
+// LoadValue 读取SaveValue保存的值，不存在时返回false
+func (s *Store) LoadValue(name string, out interface{}) (bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(metaBucket).Get([]byte("value:" + name)); v != nil {
+			data = append(data, v...)
+		}
+		return nil
+	})
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveNamedSnapshot 保存命名快照，同名快照会被覆盖
+func (s *Store) SaveNamedSnapshot(snapshot NamedSnapshot, lists map[schema.GroupVersionResource]*unstructured.UnstructuredList) error {
+	key := []byte(snapshot.Name)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		named := tx.Bucket(namedBucket)
+		if named.Bucket(key) != nil {
+			if err := named.DeleteBucket(key); err != nil {
+				return err
+			}
+		}
+		snapshotBucket, err := named.CreateBucket(key)
+		if err != nil {
+			return err
+		}
+
+		for gvr, list := range lists {
+			bucket, err := snapshotBucket.CreateBucket([]byte(gvr.String()))
+			if err != nil {
+				return err
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				data, err := obj.MarshalJSON()
+				if err != nil {
+					return fmt.Errorf("failed to encode %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+				}
+				if err := bucket.Put([]byte(objectKey(obj)), data); err != nil {
+					return err
+				}
+			}
+		}
+
+		meta, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(namedMetaBucket).Put(key, meta)
+	})
+}
+
+// LoadNamedSnapshot 读取命名快照，不存在时返回错误
+func (s *Store) LoadNamedSnapshot(name string) (NamedSnapshot, map[schema.GroupVersionResource]*unstructured.UnstructuredList, error) {
+	key := []byte(name)
+	var snapshot NamedSnapshot
+	lists := make(map[schema.GroupVersionResource]*unstructured.UnstructuredList)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		rawMeta := tx.Bucket(namedMetaBucket).Get(key)
+		snapshotBucket := tx.Bucket(namedBucket).Bucket(key)
+		if rawMeta == nil || snapshotBucket == nil {
+			return fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+		}
+		if err := json.Unmarshal(rawMeta, &snapshot); err != nil {
+			return fmt.Errorf("failed to decode snapshot metadata: %v", err)
+		}
+
+		for _, resource := range snapshot.Resources {
+			gvr := resource.GroupVersionResource()
+			list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, resource.ObjectCount)}
+			lists[gvr] = list
+
+			bucket := snapshotBucket.Bucket([]byte(gvr.String()))
+			if bucket == nil {
+				continue
+			}
+			err := bucket.ForEach(func(k, v []byte) error {
+				var obj unstructured.Unstructured
+				if err := obj.UnmarshalJSON(v); err != nil {
+					return fmt.Errorf("failed to decode %s: %v", string(k), err)
+				}
+				list.Items = append(list.Items, obj)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return NamedSnapshot{}, nil, err
+	}
+
+	return snapshot, lists, nil
+}
+
+// ListNamedSnapshots 列出所有命名快照的元数据
+func (s *Store) ListNamedSnapshots() ([]NamedSnapshot, error) {
+	snapshots := []NamedSnapshot{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(namedMetaBucket).ForEach(func(k, v []byte) error {
+			var snapshot NamedSnapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return fmt.Errorf("failed to decode snapshot metadata %s: %v", string(k), err)
+			}
+			snapshots = append(snapshots, snapshot)
+			return nil
+		})
+	})
+	return snapshots, err
+}
+
+// DeleteNamedSnapshot 删除命名快照
+func (s *Store) DeleteNamedSnapshot(name string) error {
+	key := []byte(name)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(namedMetaBucket).Get(key) == nil {
+			return fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+		}
+		if tx.Bucket(namedBucket).Bucket(key) != nil {
+			if err := tx.Bucket(namedBucket).DeleteBucket(key); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(namedMetaBucket).Delete(key)
+	})
+}
+
+// Close 关闭快照存储
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// objectKey 返回对象在bucket中的键
+func objectKey(obj *unstructured.Unstructured) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns + "/" + obj.GetName()
+	}
+	return obj.GetName()
+}
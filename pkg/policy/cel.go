@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// celCostLimit 单次求值的代价上限，防止规则遍历大对象时耗尽CPU
+const celCostLimit = 1000000
+
+// compiledValidation 编译后的规则
+type compiledValidation struct {
+	Validation
+	program        cel.Program
+	messageProgram cel.Program
+}
+
+// celValidations 策略中编译后的所有CEL规则
+type celValidations []compiledValidation
+
+// newCELEnv 创建CEL环境，object为被检查的对象
+func newCELEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		ext.Strings(),
+	)
+}
+
+// compileCEL 编译策略中的所有CEL规则
+func compileCEL(validations []Validation) (celValidations, error) {
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %v", err)
+	}
+
+	compiled := make(celValidations, 0, len(validations))
+	for i, validation := range validations {
+		program, err := compileProgram(env, validation.Expression, cel.BoolType)
+		if err != nil {
+			return nil, fmt.Errorf("validation %d: %v", i, err)
+		}
+
+		item := compiledValidation{Validation: validation, program: program}
+		if validation.MessageExpression != "" {
+			if item.messageProgram, err = compileProgram(env, validation.MessageExpression, cel.StringType); err != nil {
+				return nil, fmt.Errorf("validation %d messageExpression: %v", i, err)
+			}
+		}
+		compiled = append(compiled, item)
+	}
+
+	return compiled, nil
+}
+
+// compileProgram 编译单个表达式并检查结果类型
+func compileProgram(env *cel.Env, expression string, resultType *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.DynType && !ast.OutputType().IsExactType(resultType) {
+		return nil, fmt.Errorf("expression must evaluate to %s, got %s", resultType, ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(celCostLimit))
+}
+
+// evaluate 对单个对象求值所有规则，返回违反的规则
+func (validations celValidations) evaluate(obj *unstructured.Unstructured) []Violation {
+	activation := map[string]interface{}{"object": obj.Object}
+
+	violations := []Violation{}
+	for i, validation := range validations {
+		out, _, err := validation.program.Eval(activation)
+		if err != nil {
+			violations = append(violations, Violation{
+				Validation: i,
+				Expression: validation.Expression,
+				Message:    err.Error(),
+				Error:      true,
+			})
+			continue
+		}
+
+		passed, ok := out.Value().(bool)
+		if !ok {
+			violations = append(violations, Violation{
+				Validation: i,
+				Expression: validation.Expression,
+				Message:    fmt.Sprintf("expression must evaluate to bool, got %s", out.Type()),
+				Error:      true,
+			})
+			continue
+		}
+		if passed {
+			continue
+		}
+		violations = append(violations, Violation{
+			Validation: i,
+			Expression: validation.Expression,
+			Message:    validation.message(activation),
+		})
+	}
+
+	return violations
+}
+
+// message 计算违反规则时的提示
+func (v *compiledValidation) message(activation map[string]interface{}) string {
+	if v.messageProgram != nil {
+		if out, _, err := v.messageProgram.Eval(activation); err == nil {
+			if message, ok := out.Value().(string); ok && message != "" {
+				return message
+			}
+		}
+	}
+	if v.Message != "" {
+		return v.Message
+	}
+	return fmt.Sprintf("failed expression: %s", v.Expression)
+}
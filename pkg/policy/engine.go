@@ -0,0 +1,363 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jicki/crds-objects-browser/pkg/informer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// storeKey 策略定义在存储中的键
+const storeKey = "policies"
+
+// ObjectSource 被检查对象的来源（缓存）
+type ObjectSource interface {
+	GetObjects(gvr schema.GroupVersionResource, namespace string) ([]*unstructured.Unstructured, error)
+}
+
+// Store 策略定义的持久化存储
+type Store interface {
+	SaveValue(name string, value interface{}) error
+	LoadValue(name string, out interface{}) (bool, error)
+}
+
+// evaluator 编译后的规则，对单个对象求值并返回违反的规则
+type evaluator interface {
+	evaluate(obj *unstructured.Unstructured) []Violation
+}
+
+// compiledPolicy 编译后的策略及其检查结果
+type compiledPolicy struct {
+	Policy
+	validations evaluator
+	resources   map[schema.GroupVersionResource]bool
+	namespaces  map[string]bool
+	// results 按资源和对象键保存的检查结果
+	results     map[schema.GroupVersionResource]map[string]*ObjectResult
+	lastUpdated time.Time
+}
+
+// matches 检查对象是否在策略的命名空间范围内
+func (p *compiledPolicy) matches(obj *unstructured.Unstructured) bool {
+	return len(p.namespaces) == 0 || p.namespaces[obj.GetNamespace()]
+}
+
+// Engine 策略引擎，在缓存对象上求值策略，并通过Informer事件增量更新结果
+type Engine struct {
+	source ObjectSource
+	store  Store
+
+	mutex    sync.RWMutex
+	policies map[string]*compiledPolicy
+}
+
+// NewEngine 创建策略引擎，store为nil时策略只保存在内存中
+func NewEngine(source ObjectSource, store Store) *Engine {
+	return &Engine{
+		source:   source,
+		store:    store,
+		policies: make(map[string]*compiledPolicy),
+	}
+}
+
+// LoadPolicies 读取已保存的策略定义
+func (e *Engine) LoadPolicies() ([]Policy, error) {
+	if e.store == nil {
+		return nil, nil
+	}
+
+	var policies []Policy
+	if _, err := e.store.LoadValue(storeKey, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// compile 校验并编译策略
+func compile(policy Policy) (*compiledPolicy, error) {
+	if policy.Name == "" {
+		return nil, fmt.Errorf("policy name is required")
+	}
+	if len(policy.Resources) == 0 {
+		return nil, fmt.Errorf("policy %s: at least one resource is required", policy.Name)
+	}
+	if len(policy.Validations) == 0 {
+		return nil, fmt.Errorf("policy %s: at least one validation is required", policy.Name)
+	}
+
+	compiled := &compiledPolicy{
+		Policy:     policy,
+		resources:  make(map[schema.GroupVersionResource]bool),
+		namespaces: make(map[string]bool),
+		results:    make(map[schema.GroupVersionResource]map[string]*ObjectResult),
+	}
+	if compiled.Language == "" {
+		compiled.Language = LanguageCEL
+	}
+
+	switch compiled.Language {
+	case LanguageCEL:
+		validations, err := compileCEL(policy.Validations)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %v", policy.Name, err)
+		}
+		compiled.validations = validations
+	case LanguageRego:
+		validations, err := compileRego(policy.Validations)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %v", policy.Name, err)
+		}
+		compiled.validations = validations
+	default:
+		return nil, fmt.Errorf("policy %s: unknown language %q", policy.Name, compiled.Language)
+	}
+
+	for _, resource := range policy.Resources {
+		gvr := resource.GroupVersionResource()
+		compiled.resources[gvr] = true
+		compiled.results[gvr] = make(map[string]*ObjectResult)
+	}
+	for _, namespace := range policy.Namespaces {
+		compiled.namespaces[namespace] = true
+	}
+
+	return compiled, nil
+}
+
+// Validate 只编译策略，不保存
+func Validate(policy Policy) error {
+	_, err := compile(policy)
+	return err
+}
+
+// Apply 创建或替换策略，并对缓存中的现有对象求值
+// 调用方需先确保策略涉及资源的Informer已启动，此后的变化通过OnEvent增量更新；
+// 求值在锁外进行，不阻塞其他策略的事件处理和查询
+func (e *Engine) Apply(policy Policy) error {
+	compiled, err := compile(policy)
+	if err != nil {
+		return err
+	}
+
+	// 先注册策略再求值现有对象，避免两者之间的事件丢失
+	e.mutex.Lock()
+	e.policies[policy.Name] = compiled
+	e.mutex.Unlock()
+
+	for gvr := range compiled.resources {
+		objects, err := e.source.GetObjects(gvr, "")
+		if err != nil {
+			klog.Warningf("Failed to get %s objects for policy %s: %v", gvr.String(), policy.Name, err)
+			continue
+		}
+
+		results := make(map[string]*ObjectResult, len(objects))
+		for _, obj := range objects {
+			results[objectKey(obj)] = compiled.evaluate(gvr, obj)
+		}
+
+		e.mutex.Lock()
+		for key, result := range results {
+			// 已由事件更新过的对象不再用可能更旧的缓存副本覆盖
+			if _, exists := compiled.results[gvr][key]; !exists {
+				compiled.storeLocked(gvr, key, result)
+			}
+		}
+		e.mutex.Unlock()
+	}
+
+	e.save()
+	klog.Infof("Applied policy %s on %d resources", policy.Name, len(compiled.resources))
+	return nil
+}
+
+// Delete 删除策略，返回被删除策略涉及的资源
+func (e *Engine) Delete(name string) ([]schema.GroupVersionResource, bool) {
+	e.mutex.Lock()
+	compiled, ok := e.policies[name]
+	delete(e.policies, name)
+	e.mutex.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	e.save()
+	var resources []schema.GroupVersionResource
+	for gvr := range compiled.resources {
+		resources = append(resources, gvr)
+	}
+	return resources, true
+}
+
+// Get 返回策略定义
+func (e *Engine) Get(name string) (Policy, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	compiled, ok := e.policies[name]
+	if !ok {
+		return Policy{}, false
+	}
+	return compiled.Policy, true
+}
+
+// List 返回所有策略的汇总
+func (e *Engine) List() []Summary {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	summaries := make([]Summary, 0, len(e.policies))
+	for _, compiled := range e.policies {
+		summaries = append(summaries, compiled.summaryLocked())
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Policy < summaries[j].Policy })
+	return summaries
+}
+
+// Report 返回策略的检查报告，onlyViolations为true时只包含违反策略的对象
+func (e *Engine) Report(name, namespace string, onlyViolations bool) (Report, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	compiled, ok := e.policies[name]
+	if !ok {
+		return Report{}, false
+	}
+
+	report := Report{
+		Summary: compiled.summaryLocked(),
+		Policy:  compiled.Policy,
+		Results: []ObjectResult{},
+	}
+	for _, results := range compiled.results {
+		for _, result := range results {
+			if namespace != "" && result.Namespace != namespace {
+				continue
+			}
+			if onlyViolations && len(result.Violations) == 0 {
+				continue
+			}
+			report.Results = append(report.Results, *result)
+		}
+	}
+
+	sort.Slice(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report, true
+}
+
+// OnEvent 处理Informer事件，增量更新相关策略的结果，可作为informer.EventListener注册
+// 规则在锁外求值，只在保存结果时持有写锁，避免求值耗时阻塞其他资源的事件和查询
+func (e *Engine) OnEvent(gvr schema.GroupVersionResource, eventType informer.EventType, obj *unstructured.Unstructured) {
+	e.mutex.RLock()
+	var matched []*compiledPolicy
+	for _, compiled := range e.policies {
+		if compiled.resources[gvr] {
+			matched = append(matched, compiled)
+		}
+	}
+	e.mutex.RUnlock()
+
+	key := objectKey(obj)
+	for _, compiled := range matched {
+		var result *ObjectResult
+		if eventType != informer.EventDeleted {
+			result = compiled.evaluate(gvr, obj)
+		}
+
+		e.mutex.Lock()
+		// 求值期间策略被替换或删除时丢弃结果
+		if e.policies[compiled.Name] == compiled {
+			compiled.storeLocked(gvr, key, result)
+		}
+		e.mutex.Unlock()
+	}
+}
+
+// evaluate 求值单个对象，对象不在策略的命名空间范围内时返回nil；
+// 编译后的规则只读，可以在不持有引擎锁时调用
+func (p *compiledPolicy) evaluate(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) *ObjectResult {
+	if !p.matches(obj) {
+		return nil
+	}
+	return &ObjectResult{
+		Group:      gvr.Group,
+		Version:    gvr.Version,
+		Resource:   gvr.Resource,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Violations: p.validations.evaluate(obj),
+		CheckedAt:  time.Now(),
+	}
+}
+
+// storeLocked 保存对象的检查结果，result为nil时删除（对象被删除或不在范围内），调用方需持有写锁
+func (p *compiledPolicy) storeLocked(gvr schema.GroupVersionResource, key string, result *ObjectResult) {
+	if result == nil {
+		delete(p.results[gvr], key)
+	} else {
+		p.results[gvr][key] = result
+	}
+	p.lastUpdated = time.Now()
+}
+
+// summaryLocked 计算策略汇总
+func (p *compiledPolicy) summaryLocked() Summary {
+	summary := Summary{
+		Policy:      p.Name,
+		Language:    p.Language,
+		Resources:   len(p.resources),
+		LastUpdated: p.lastUpdated,
+	}
+	for _, results := range p.results {
+		for _, result := range results {
+			summary.Objects++
+			if len(result.Violations) > 0 {
+				summary.Violating++
+				summary.Violations += len(result.Violations)
+			}
+		}
+	}
+	return summary
+}
+
+// save 保存所有策略定义
+func (e *Engine) save() {
+	if e.store == nil {
+		return
+	}
+
+	e.mutex.RLock()
+	policies := make([]Policy, 0, len(e.policies))
+	for _, compiled := range e.policies {
+		policies = append(policies, compiled.Policy)
+	}
+	e.mutex.RUnlock()
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	if err := e.store.SaveValue(storeKey, policies); err != nil {
+		klog.Warningf("Failed to save policies: %v", err)
+	}
+}
+
+// objectKey 对象在结果中的键
+func objectKey(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// regoEvalTimeout 单次求值的时间上限，防止规则遍历大对象时耗尽CPU
+const regoEvalTimeout = time.Second
+
+// compiledRegoValidation 编译后的Rego规则
+type compiledRegoValidation struct {
+	Validation
+	query        rego.PreparedEvalQuery
+	messageQuery *rego.PreparedEvalQuery
+}
+
+// regoValidations 策略中编译后的所有Rego规则
+type regoValidations []compiledRegoValidation
+
+// compileRego 编译策略中的所有Rego规则，每条规则是一个查询，被检查的对象为input.object
+func compileRego(validations []Validation) (regoValidations, error) {
+	ctx := context.Background()
+
+	compiled := make(regoValidations, 0, len(validations))
+	for i, validation := range validations {
+		query, err := prepareRegoQuery(ctx, validation.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("validation %d: %v", i, err)
+		}
+
+		item := compiledRegoValidation{Validation: validation, query: query}
+		if validation.MessageExpression != "" {
+			messageQuery, err := prepareRegoQuery(ctx, validation.MessageExpression)
+			if err != nil {
+				return nil, fmt.Errorf("validation %d messageExpression: %v", i, err)
+			}
+			item.messageQuery = &messageQuery
+		}
+		compiled = append(compiled, item)
+	}
+
+	return compiled, nil
+}
+
+// prepareRegoQuery 解析并编译单个查询
+func prepareRegoQuery(ctx context.Context, query string) (rego.PreparedEvalQuery, error) {
+	return rego.New(
+		rego.Query(query),
+		rego.StrictBuiltinErrors(true),
+	).PrepareForEval(ctx)
+}
+
+// evaluate 对单个对象求值所有规则，返回违反的规则
+// 查询有结果时通过；没有结果（表达式为false或引用的字段不存在）时违反规则
+func (validations regoValidations) evaluate(obj *unstructured.Unstructured) []Violation {
+	input := map[string]interface{}{"object": obj.Object}
+
+	violations := []Violation{}
+	for i, validation := range validations {
+		results, err := evalRego(validation.query, input)
+		if err != nil {
+			violations = append(violations, Violation{
+				Validation: i,
+				Expression: validation.Expression,
+				Message:    err.Error(),
+				Error:      true,
+			})
+			continue
+		}
+		if len(results) > 0 {
+			continue
+		}
+		violations = append(violations, Violation{
+			Validation: i,
+			Expression: validation.Expression,
+			Message:    validation.message(input),
+		})
+	}
+
+	return violations
+}
+
+// message 计算违反规则时的提示，messageExpression取第一个结果的第一个表达式的字符串值
+func (v *compiledRegoValidation) message(input map[string]interface{}) string {
+	if v.messageQuery != nil {
+		if results, err := evalRego(*v.messageQuery, input); err == nil && len(results) > 0 && len(results[0].Expressions) > 0 {
+			if message, ok := results[0].Expressions[0].Value.(string); ok && message != "" {
+				return message
+			}
+		}
+	}
+	if v.Message != "" {
+		return v.Message
+	}
+	return fmt.Sprintf("failed expression: %s", v.Expression)
+}
+
+// evalRego 在时间上限内求值查询
+func evalRego(query rego.PreparedEvalQuery, input map[string]interface{}) (rego.ResultSet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), regoEvalTimeout)
+	defer cancel()
+	return query.Eval(ctx, rego.EvalInput(input))
+}
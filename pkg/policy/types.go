@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// 策略语言
+const (
+	LanguageCEL  = "cel"
+	LanguageRego = "rego"
+)
+
+// Resource 策略作用的资源
+type Resource struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+}
+
+// GroupVersionResource 转换为GVR
+func (r Resource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// Validation 单条校验规则，CEL规则与ValidatingAdmissionPolicy的validations字段含义相同：
+// Expression求值为false时对象违反规则；Rego规则是以input.object为对象的查询，没有结果时违反规则
+type Validation struct {
+	Expression string `json:"expression"`
+	// Message 违反规则时的提示
+	Message string `json:"message,omitempty"`
+	// MessageExpression 计算提示的表达式（与策略语言相同），优先于Message
+	MessageExpression string `json:"messageExpression,omitempty"`
+}
+
+// Policy 用户定义的策略
+type Policy struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Language 规则语言，默认cel
+	Language  string     `json:"language,omitempty"`
+	Resources []Resource `json:"resources"`
+	// Namespaces 只检查这些命名空间中的对象，为空时检查所有对象
+	Namespaces  []string     `json:"namespaces,omitempty"`
+	Validations []Validation `json:"validations"`
+}
+
+// Violation 对象违反的规则
+type Violation struct {
+	Validation int    `json:"validation"`
+	Expression string `json:"expression"`
+	Message    string `json:"message"`
+	// Error 为true表示规则求值出错（如字段不存在），按违反处理
+	Error bool `json:"error,omitempty"`
+}
+
+// ObjectResult 单个对象的检查结果
+type ObjectResult struct {
+	Group      string      `json:"group"`
+	Version    string      `json:"version"`
+	Resource   string      `json:"resource"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Violations []Violation `json:"violations"`
+	CheckedAt  time.Time   `json:"checkedAt"`
+}
+
+// Summary 策略检查汇总
+type Summary struct {
+	Policy      string    `json:"policy"`
+	Language    string    `json:"language"`
+	Resources   int       `json:"resources"`
+	Objects     int       `json:"objects"`
+	Violating   int       `json:"violating"`
+	Violations  int       `json:"violations"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// Report 策略检查报告
+type Report struct {
+	Summary
+	Policy  Policy         `json:"definition"`
+	Results []ObjectResult `json:"results"`
+}